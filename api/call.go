@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 	"vaxctl/helpers"
 
 	"github.com/spf13/viper"
@@ -113,6 +114,10 @@ func UpdateResolvedState(UpdateResolvedStateData []byte) ([]byte, error) {
 	return runQuery("state/update-resolve", "POST", UpdateResolvedStateData, url.Values{})
 }
 
+func SendHeartbeat(heartbeatData []byte) ([]byte, error) {
+	return runQuery("device/heartbeat", "POST", heartbeatData, url.Values{})
+}
+
 func SetCredsAsDefault(name string) ([]byte, error) {
 	paramValues := url.Values{}
 	paramValues.Set("name", name)
@@ -263,12 +268,29 @@ func UpdateResourceFromFile(resource string, filename string) ([]byte, error) {
 	}
 }
 
-func runQuery(urlPath string, method string, body []byte, params url.Values) ([]byte, error) {
+// BaseURL returns the configured server URL.
+func BaseURL() string {
 	baseUrl := viper.GetString("url")
 	if baseUrl == "" {
 		baseUrl = "http://localhost:5000"
 	}
-	client := &http.Client{}
+	return baseUrl
+}
+
+func runQuery(urlPath string, method string, body []byte, params url.Values) ([]byte, error) {
+	baseUrl := BaseURL()
+	skip, err := skipRequest(method, urlPath, params.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if skip {
+		return []byte("{}"), nil
+	}
+	transport, err := getTransport()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
 	reqUrl := baseUrl + "/api/v1/" + urlPath + "?" + params.Encode()
 	requestBody := bytes.NewBuffer(body)
 
@@ -280,13 +302,16 @@ func runQuery(urlPath string, method string, body []byte, params url.Values) ([]
 
 	request.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	response, err := client.Do(request)
 	if err != nil {
+		traceRequest(request, body, nil, nil, time.Since(start), err)
 		return nil, err
 	}
 
 	defer response.Body.Close()
 	responseData, err := ioutil.ReadAll(response.Body)
+	traceRequest(request, body, response, responseData, time.Since(start), err)
 	if err != nil {
 		return nil, err
 	}
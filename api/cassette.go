@@ -0,0 +1,347 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+const apiPathPrefix = "/api/v1/"
+
+// The cassette is stored as a HAR 1.2 file so it can also be opened with
+// any tool that understands the format (browsers, HAR viewers, etc.)
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type recordingTransport struct {
+	filename  string
+	transport http.RoundTripper
+	lock      sync.Mutex
+	file      *os.File
+}
+
+type replayTransport struct {
+	filename string
+	lock     sync.Mutex
+	har      harFile
+	used     []bool
+}
+
+var (
+	transportOnce sync.Once
+	transport     http.RoundTripper
+	transportErr  error
+)
+
+// getTransport returns the transport for all API calls, honoring the
+// 'record' and 'replay' settings. It is created once so a single cassette
+// is shared by every request made during the run.
+func getTransport() (http.RoundTripper, error) {
+	transportOnce.Do(func() {
+		recordFile := viper.GetString("record")
+		replayFile := viper.GetString("replay")
+		switch {
+		case recordFile != "" && replayFile != "":
+			transportErr = errors.New("--record and --replay can't be used together")
+		case replayFile != "":
+			transport, transportErr = newReplayTransport(replayFile)
+		case recordFile != "":
+			transport = newRecordingTransport(recordFile)
+		default:
+			transport = http.DefaultTransport
+		}
+	})
+	return transport, transportErr
+}
+
+// harTrailer closes the entries list and the HAR document, new entries are
+// written over it.
+const harTrailer = "\n]}}\n"
+
+func newRecordingTransport(filename string) *recordingTransport {
+	return &recordingTransport{filename: filename, transport: http.DefaultTransport}
+}
+
+func (t *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		requestBody, _ = ioutil.ReadAll(request.Body)
+		request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	start := time.Now()
+	response, err := t.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      request.Method,
+			URL:         request.URL.String(),
+			HTTPVersion: request.Proto,
+			Headers:     toHarNameValues(request.Header),
+			QueryString: toHarNameValues(request.URL.Query()),
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Response: harResponse{
+			Status:      response.StatusCode,
+			StatusText:  http.StatusText(response.StatusCode),
+			HTTPVersion: response.Proto,
+			Headers:     toHarNameValues(response.Header),
+			Content:     toHarContent(responseBody, response.Header.Get("Content-Type")),
+			HeadersSize: -1,
+			BodySize:    len(responseBody),
+		},
+		Timings: harTimings{Send: 0, Wait: elapsed, Receive: 0},
+	}
+	if len(requestBody) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: request.Header.Get("Content-Type"), Text: string(requestBody)}
+	}
+
+	err = t.appendEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// appendEntry writes the entry in place of the trailer of the file so it is
+// a complete HAR file after every request (commands may exit at any point)
+// without rewriting the previous entries.
+func (t *recordingTransport) appendEntry(entry harEntry) error {
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.file == nil {
+		t.file, err = os.OpenFile(t.filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		headerData, _ := json.Marshal(harFile{Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "vaxctl", Version: "1"},
+			Entries: []harEntry{},
+		}})
+		// the header is the empty document without its '[]}}' ending
+		header := strings.TrimSuffix(string(headerData), "]}}") + "\n"
+		_, err = t.file.WriteString(header + string(entryData) + harTrailer)
+		return err
+	}
+	_, err = t.file.Seek(-int64(len(harTrailer)), io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	_, err = t.file.WriteString(",\n" + string(entryData) + harTrailer)
+	return err
+}
+
+func newReplayTransport(filename string) (*replayTransport, error) {
+	harData, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var har harFile
+	err = json.Unmarshal(harData, &har)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replay file '%s': %v", filename, err)
+	}
+	return &replayTransport{filename: filename, har: har, used: make([]bool, len(har.Log.Entries))}, nil
+}
+
+// RoundTrip serves the first unused recorded entry matching the request,
+// falling back to the last matching one so repeated reads keep working.
+// Entries are matched on method, API path, query and body, the server
+// address is ignored so a cassette can be replayed with any config.
+func (t *replayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		requestBody, _ = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+	}
+	requestKey := replayKey(request.Method, request.URL.String())
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	matchedIdx := -1
+	for idx, entry := range t.har.Log.Entries {
+		if replayKey(entry.Request.Method, entry.Request.URL) != requestKey {
+			continue
+		}
+		var recordedBody string
+		if entry.Request.PostData != nil {
+			recordedBody = entry.Request.PostData.Text
+		}
+		if !equalBodies([]byte(recordedBody), requestBody) {
+			continue
+		}
+		matchedIdx = idx
+		if !t.used[idx] {
+			break
+		}
+	}
+	if matchedIdx == -1 {
+		return nil, fmt.Errorf("no recorded response for %s in '%s'", requestKey, t.filename)
+	}
+	t.used[matchedIdx] = true
+
+	recorded := t.har.Log.Entries[matchedIdx].Response
+	responseBody, err := fromHarContent(recorded.Content)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	for _, nameValue := range recorded.Headers {
+		header.Add(nameValue.Name, nameValue.Value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, recorded.StatusText),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       request,
+	}, nil
+}
+
+func replayKey(method string, requestUrl string) string {
+	if idx := strings.Index(requestUrl, apiPathPrefix); idx != -1 {
+		requestUrl = requestUrl[idx+len(apiPathPrefix):]
+	}
+	return method + " " + strings.TrimSuffix(requestUrl, "?")
+}
+
+func equalBodies(first []byte, second []byte) bool {
+	if bytes.Equal(bytes.TrimSpace(first), bytes.TrimSpace(second)) {
+		return true
+	}
+	var firstData, secondData interface{}
+	if json.Unmarshal(first, &firstData) != nil || json.Unmarshal(second, &secondData) != nil {
+		return false
+	}
+	firstNormalized, _ := json.Marshal(firstData)
+	secondNormalized, _ := json.Marshal(secondData)
+	return bytes.Equal(firstNormalized, secondNormalized)
+}
+
+func toHarNameValues(values map[string][]string) []harNameValue {
+	nameValues := []harNameValue{}
+	for name, nameValueList := range values {
+		for _, value := range nameValueList {
+			nameValues = append(nameValues, harNameValue{Name: name, Value: value})
+		}
+	}
+	return nameValues
+}
+
+func toHarContent(body []byte, mimeType string) harContent {
+	content := harContent{Size: len(body), MimeType: mimeType}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+func fromHarContent(content harContent) ([]byte, error) {
+	if content.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(content.Text)
+	}
+	return []byte(content.Text), nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// testdata/create_action.har is the recording of
+// 'vaxctl create action reboot-now --type power --data cycle'.
+func TestReplayCassette(t *testing.T) {
+	viper.Set("replay", "testdata/create_action.har")
+	// the server address is ignored on replay
+	viper.Set("url", "http://vaxiin.invalid:5000")
+	defer viper.Reset()
+
+	tests := []struct {
+		name    string
+		call    func() ([]byte, error)
+		want    string
+		wantErr string
+	}{
+		{
+			name: "action types",
+			call: GetActionTypes,
+			want: `"action_types":["sleep","power","ipmitool","keystroke","request"]`,
+		},
+		{
+			name: "power options",
+			call: GetPowerOptions,
+			want: `"cycle"`,
+		},
+		{
+			name: "missing action",
+			call: func() ([]byte, error) {
+				return GetResourceByName("action", "reboot-now")
+			},
+			wantErr: "Request returned 404 status.\nError: action 'reboot-now' was not found",
+		},
+		{
+			name: "create action with reordered fields",
+			call: func() ([]byte, error) {
+				return PostResourceFromBytes("action", []byte(`{"name":"reboot-now","action_type":"power","action_data":"cycle"}`))
+			},
+			want: `"name":"reboot-now"`,
+		},
+		{
+			name: "unrecorded request",
+			call: func() ([]byte, error) {
+				return GetResourceByName("action", "press-f1")
+			},
+			wantErr: "no recorded response for GET action/?name=press-f1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.call()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(data), test.want) {
+				t.Fatalf("got %s, want it to contain %s", data, test.want)
+			}
+		})
+	}
+}
+
+func TestRecordCassette(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		fmt.Fprintf(writer, `{"path":%q,"body":%q}`, request.URL.Path, body)
+	}))
+	defer server.Close()
+	filename := filepath.Join(t.TempDir(), "session.har")
+	recorder := newRecordingTransport(filename)
+	client := &http.Client{Transport: recorder}
+
+	paths := []string{"action/all", "rule/ordered", "creds/default"}
+	for idx, path := range paths {
+		response, err := client.Post(server.URL+apiPathPrefix+path, "application/json", strings.NewReader(`{"idx":1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		// the file must be complete after every request
+		harData, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var har harFile
+		err = json.Unmarshal(harData, &har)
+		if err != nil {
+			t.Fatalf("invalid HAR file after %d requests: %v", idx+1, err)
+		}
+		if len(har.Log.Entries) != idx+1 {
+			t.Fatalf("got %d entries after %d requests", len(har.Log.Entries), idx+1)
+		}
+	}
+
+	player, err := newReplayTransport(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: player}
+	response, err := client.Post("http://other-server"+apiPathPrefix+"rule/ordered", "application/json", strings.NewReader(`{ "idx": 1 }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(response.Body)
+	want := `{"path":"/api/v1/rule/ordered","body":"{\"idx\":1}"}`
+	if string(body) != want {
+		t.Fatalf("got %s, want %s", body, want)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Contexts are named servers in the config file:
+//
+//	contexts:
+//	  lab:
+//	    url: http://lab-vaxiin:5000
+//	  prod:
+//	    url: http://prod-vaxiin:5000
+//	current-context: lab
+//
+// ContextURL returns the URL of a context, URLs are returned as is.
+func ContextURL(name string) (string, error) {
+	if strings.Contains(name, "://") {
+		return name, nil
+	}
+	if !viper.IsSet("contexts." + name) {
+		return "", fmt.Errorf("context '%s' is not defined in the config file", name)
+	}
+	contextUrl := viper.GetString("contexts." + name + ".url")
+	if contextUrl == "" {
+		return "", fmt.Errorf("context '%s' has no url", name)
+	}
+	return contextUrl, nil
+}
+
+// UseContext sends all following requests to the context's server.
+func UseContext(name string) error {
+	contextUrl, err := ContextURL(name)
+	if err != nil {
+		return err
+	}
+	UseServer(contextUrl)
+	return nil
+}
+
+// UseServer sends all following requests to the server URL.
+func UseServer(serverUrl string) {
+	viper.Set("url", serverUrl)
+}
+
+// SelectServer picks the server of the run, from the highest precedence:
+// an explicit URL (the '--url' flag or the URL environment variable), the
+// '--context' flag, the 'current-context' and the 'url' of the config file.
+func SelectServer(explicitUrl bool) error {
+	if explicitUrl {
+		return nil
+	}
+	if viper.GetString("context") != "" {
+		return UseContext(viper.GetString("context"))
+	}
+	if viper.GetString("current-context") != "" {
+		return UseContext(viper.GetString("current-context"))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Dry run modes, on 'client' mode requests that change the server are only
+// printed (with secrets masked) while GET requests are still sent so that
+// lookups (e.g. POST vs. PUT) work as usual.
+const (
+	DryRunNone   = "none"
+	DryRunClient = "client"
+)
+
+var (
+	// DryRunOutput is where the skipped requests are printed
+	DryRunOutput  io.Writer = os.Stdout
+	lastDryRun    string
+	lastDryRunMux sync.Mutex
+)
+
+// DryRunMode returns the configured mode, it fails for unknown modes.
+func DryRunMode() (string, error) {
+	mode := viper.GetString("dry-run")
+	switch mode {
+	case "", DryRunNone:
+		return DryRunNone, nil
+	case DryRunClient:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("dry run mode '%s' is not valid (allowed values are: none & client)", mode)
+	}
+}
+
+func IsDryRun() bool {
+	mode, _ := DryRunMode()
+	return mode == DryRunClient
+}
+
+// LastDryRunRequest returns the method and path of the last skipped request.
+func LastDryRunRequest() string {
+	lastDryRunMux.Lock()
+	defer lastDryRunMux.Unlock()
+	return lastDryRun
+}
+
+// skipRequest reports whether the request should not be sent, in which case
+// it's printed instead.
+func skipRequest(method string, urlPath string, query string, body []byte) (bool, error) {
+	mode, err := DryRunMode()
+	if err != nil || mode == DryRunNone || method == http.MethodGet {
+		return false, err
+	}
+	request := fmt.Sprintf("%s %s%s", method, apiPathPrefix, urlPath)
+	if query != "" {
+		request += "?" + query
+	}
+	lastDryRunMux.Lock()
+	lastDryRun = request
+	lastDryRunMux.Unlock()
+
+	fmt.Fprintf(DryRunOutput, "[dry-run] %s\n", request)
+	if len(body) > 0 {
+		maskedBody := redactBody(body, "application/json")
+		var indentedBody bytes.Buffer
+		if json.Indent(&indentedBody, []byte(maskedBody), "", "  ") == nil {
+			maskedBody = indentedBody.String()
+		}
+		fmt.Fprintln(DryRunOutput, maskedBody)
+	}
+	return true, nil
+}
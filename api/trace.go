@@ -0,0 +1,199 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+// Verbosity levels:
+//
+//	1 - method, URL, status and latency
+//	2 - request/response headers
+//	3 - request/response bodies (truncated)
+//	4 - full request/response bodies
+//
+// Secrets are redacted on every level.
+const (
+	traceLevelRequests  = 1
+	traceLevelHeaders   = 2
+	traceLevelBodies    = 3
+	traceLevelFullBody  = 4
+	truncatedBodyLength = 1024
+	truncatedValueSize  = 64
+	minSecretLength     = 4
+	redactedValue       = "<redacted>"
+)
+
+var (
+	sensitiveFields  = []string{"password"}
+	truncatedFields  = []string{"screenshot"}
+	sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	knownSecrets     = map[string]bool{}
+	knownSecretsLock sync.Mutex
+)
+
+func traceLevel() int {
+	return viper.GetInt("verbosity")
+}
+
+func traceRequest(request *http.Request, body []byte, response *http.Response, responseData []byte, elapsed time.Duration, err error) {
+	level := traceLevel()
+	if level < traceLevelRequests {
+		return
+	}
+	// learn secrets from both sides before printing anything
+	requestBody := redactBody(body, request.Header.Get("Content-Type"))
+	var responseBody string
+	if response != nil {
+		responseBody = redactBody(responseData, response.Header.Get("Content-Type"))
+	}
+
+	if err != nil {
+		traceLine("%s %s failed after %v: %v", request.Method, request.URL.String(), elapsed.Round(time.Millisecond), err)
+	} else {
+		traceLine("%s %s %s in %v", request.Method, request.URL.String(), response.Status, elapsed.Round(time.Millisecond))
+	}
+
+	if level >= traceLevelHeaders {
+		traceHeaders("Request", request.Header)
+		if response != nil {
+			traceHeaders("Response", response.Header)
+		}
+	}
+	if level >= traceLevelBodies {
+		if len(body) > 0 {
+			traceLine("Request body: %s", truncateBody(requestBody, level))
+		}
+		if response != nil && len(responseData) > 0 {
+			traceLine("Response body: %s", truncateBody(responseBody, level))
+		}
+	}
+}
+
+func traceLine(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", time.Now().Format("15:04:05.000"), redactSecrets(fmt.Sprintf(format, args...)))
+}
+
+func traceHeaders(title string, header http.Header) {
+	var keys []string
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := strings.Join(header[key], ", ")
+		for _, sensitiveHeader := range sensitiveHeaders {
+			if strings.EqualFold(key, sensitiveHeader) {
+				value = redactedValue
+			}
+		}
+		traceLine("%s header: %s: %s", title, key, value)
+	}
+}
+
+func truncateBody(body string, level int) string {
+	if level < traceLevelFullBody && len(body) > truncatedBodyLength {
+		return fmt.Sprintf("%s... (%d bytes total)", truncateAtRune(body, truncatedBodyLength), len(body))
+	}
+	return body
+}
+
+// truncateAtRune cuts the text to at most size bytes without splitting a
+// multi-byte character.
+func truncateAtRune(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return text[:size]
+}
+
+// redactBody returns a printable version of the body with sensitive fields
+// replaced and large base64 values truncated. Passwords found in the body are
+// remembered so that their resolved values are redacted from later output too.
+func redactBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !utf8.Valid(body) || strings.HasPrefix(contentType, "image/") {
+		return fmt.Sprintf("<binary %d bytes>", len(body))
+	}
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return redactSecrets(string(body))
+	}
+	var redactedData bytes.Buffer
+	encoder := json.NewEncoder(&redactedData)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(redactValue(data, ""))
+	return redactSecrets(strings.TrimSpace(redactedData.String()))
+}
+
+func redactValue(value interface{}, field string) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, nestedValue := range typedValue {
+			typedValue[key] = redactValue(nestedValue, key)
+		}
+		return typedValue
+	case []interface{}:
+		for idx, nestedValue := range typedValue {
+			typedValue[idx] = redactValue(nestedValue, field)
+		}
+		return typedValue
+	case string:
+		if isFieldInList(field, sensitiveFields) {
+			rememberSecret(typedValue)
+			return redactedValue
+		}
+		if isFieldInList(field, truncatedFields) && len(typedValue) > truncatedValueSize {
+			return fmt.Sprintf("%s... (%d bytes)", truncateAtRune(typedValue, truncatedValueSize), len(typedValue))
+		}
+		return typedValue
+	default:
+		return value
+	}
+}
+
+func isFieldInList(field string, fields []string) bool {
+	for _, listField := range fields {
+		if strings.EqualFold(field, listField) {
+			return true
+		}
+	}
+	return false
+}
+
+func rememberSecret(secret string) {
+	// very short values would mangle unrelated output, they are still
+	// redacted by field name
+	if len(secret) < minSecretLength {
+		return
+	}
+	knownSecretsLock.Lock()
+	defer knownSecretsLock.Unlock()
+	knownSecrets[secret] = true
+}
+
+// redactSecrets replaces every previously seen password, e.g. one that was
+// resolved from a '{cred::password}' placeholder by the server.
+func redactSecrets(text string) string {
+	knownSecretsLock.Lock()
+	defer knownSecretsLock.Unlock()
+	for secret := range knownSecrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+	return text
+}
@@ -1,16 +1,66 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
 	"github.com/spf13/cobra"
 )
 
 var applyCmd = &cobra.Command{
-	Use:   "apply <action|device|rule|state> -f FILENAME",
-	Short: "Create/Update a resource from file",
-	Long:  `Create/Update a resource from a file (JSON and YAML formats are accepted)`,
-	Args:  cobra.NoArgs,
+	Use:   "apply [action|cred|device|rule|state] -f FILENAME",
+	Short: "Create/Update resources from files",
+	Long: `Create/Update resources from files (JSON and YAML formats are accepted).
+
+Without a sub-command the files may hold multiple '---' separated documents,
+each one selecting its resource with a 'kind' field (cred, device, action, rule,
+state or work). Documents are applied after the resources they reference in
+the same bundle (creds before devices, actions before rules and rule anchors
+before the rules placed after/before them).
+
+Examples:
+  # apply all documents in a file
+  vaxctl apply -f fleet.yaml
+
+  # apply all YAML/JSON files in a directory and its sub-directories
+  vaxctl apply -f manifests/ -R
+
+  # apply documents from stdin
+  cat fleet.yaml | vaxctl apply -f -
+
+  # apply templated documents
+  vaxctl apply -f devices.yaml --values dc1.yaml --set rack=A1
+
+  # apply an overlay (a directory with a 'vaxctl-overlay.yaml' file)
+  vaxctl apply -f overlays/prod/`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(filenames) == 0 {
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.ApplyManifests(manifestSource())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
 }
 
 func init() {
 	rootCmd.AddCommand(applyCmd)
+	addManifestFlags(applyCmd, "files, directories, overlays or '-' (stdin) to create/update the resources from")
+}
+
+// addManifestFlags adds the flags of the commands that read manifests.
+func addManifestFlags(cmd *cobra.Command, filenameUsage string) {
+	cmd.Flags().StringSliceVarP(&filenames, "filename", "f", nil, filenameUsage)
+	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "process directories recursively")
+	cmd.Flags().StringSliceVar(&valuesFiles, "values", nil, "render the files as Go templates with the values from the YAML/JSON files ('.Values')")
+	cmd.Flags().StringArrayVar(&setValues, "set", nil, "render the files as Go templates with the value (KEY.PATH=VALUE, overrides --values)")
+}
+
+func manifestSource() model.ManifestSource {
+	return model.ManifestSource{Paths: filenames, Recursive: recursive, ValuesFiles: valuesFiles, SetValues: setValues}
 }
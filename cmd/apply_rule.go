@@ -13,7 +13,8 @@ var applyRuleCmd = &cobra.Command{
 	Short: "Create/Update rule from file",
 	Long: `Create/Update a new rule from file.
 
-JSON and YAML formats are accepted.
+JSON and YAML formats are accepted, the macro references of the actions
+(macro:NAME or macro:NAME(PARAM=VALUE,...)) are expanded.
   
 Examples:
   # apply rule from json
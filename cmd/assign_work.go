@@ -22,6 +22,9 @@ Examples:
 		
   # Assign actions to device
   vaxctl assign work -d DEVICE_UID -a "Press F1, Press F2"
+
+  # Assign the actions of a macro to device
+  vaxctl assign work -d DEVICE_UID -a "macro:enter-setup(key=F2,wait=3)"
 	
   # Assign work to device from file
   vaxctl assign work -f work_assignment.yaml`,
@@ -39,7 +42,7 @@ Examples:
 				os.Exit(2)
 			}
 		}
-		err := model.AssignWork(deviceUid, name, actionsList, filename)
+		err := model.AssignWork(deviceUid, name, model.JoinMacroReferences(actionsList), filename)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
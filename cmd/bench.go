@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var benchOptions model.BenchOptions
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Run a load test against the server",
+	Long: `Run a load test against the server.
+
+Creates synthetic creds, rules and devices, posts states at the requested rate
+using concurrent workers and prints latency percentiles and error rates for
+every API call. Devices are tagged with the 'vaxctl-bench' metadata key and
+other resources are prefixed with 'bench-RUN_ID-' so they can be cleaned up.
+
+Interrupting the run (Ctrl-C) stops the setup or the load and still cleans up,
+interrupting it again exits right away.
+
+Note: states can't be deleted through the API and are left on the server.
+
+Examples:
+  # 5000 devices, 100 states per second for 5 minutes
+  vaxctl bench --devices 5000 --rate 100 --duration 5m --workers 32
+
+  # clean up a previous run that was interrupted
+  vaxctl bench --cleanup-only --run-id RUN_ID`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if benchOptions.CleanupOnly && !cmd.Flags().Changed("run-id") {
+			fmt.Println("'--run-id' must be set when using '--cleanup-only'")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		if benchOptions.Workers < 1 {
+			fmt.Println("'--workers' must be at least 1")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		// the rate is turned into the interval between states
+		if benchOptions.Rate < 1 || benchOptions.Rate > int(time.Second) {
+			fmt.Printf("'--rate' must be between 1 and %d\n", int(time.Second))
+			cmd.Usage()
+			os.Exit(2)
+		}
+		if benchOptions.Devices < 0 || benchOptions.Creds < 0 || benchOptions.Rules < 0 {
+			fmt.Println("'--devices', '--creds' and '--rules' can't be negative")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.RunBench(benchOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().StringVar(&benchOptions.RunId, "run-id", model.NewBenchRunId(), "ID used to tag the created resources")
+	benchCmd.Flags().IntVar(&benchOptions.Devices, "devices", 100, "number of devices to create")
+	benchCmd.Flags().IntVar(&benchOptions.Creds, "creds", 5, "number of creds to create")
+	benchCmd.Flags().IntVar(&benchOptions.Rules, "rules", 10, "number of rules to create")
+	benchCmd.Flags().IntVarP(&benchOptions.Workers, "workers", "w", 8, "number of concurrent workers")
+	benchCmd.Flags().IntVarP(&benchOptions.Rate, "rate", "r", 10, "states to post per second")
+	benchCmd.Flags().DurationVarP(&benchOptions.Duration, "duration", "t", 30*time.Second, "how long to post states for")
+	benchCmd.Flags().BoolVar(&benchOptions.Cleanup, "cleanup", true, "delete the created resources when done")
+	benchCmd.Flags().BoolVar(&benchOptions.CleanupOnly, "cleanup-only", false, "only delete the resources of a previous run")
+}
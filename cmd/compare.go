@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateOptions model.MigrateOptions
+
+var compareCmd = &cobra.Command{
+	Use:   "compare --from CONTEXT --to CONTEXT",
+	Short: "List the resources that differ between two servers",
+	Long: `List the resources that differ between two servers and whether their rules are ordered differently.
+
+The servers are contexts from the config file (or URLs):
+
+  contexts:
+    lab:
+      url: http://lab-vaxiin:5000
+    prod:
+      url: http://prod-vaxiin:5000
+  current-context: lab
+
+Other commands use the server set with '--url' (or the URL environment
+variable), then the '--context' one, then 'current-context' and last the 'url'
+of the config file.
+
+The exit code is 0 when there are no differences and 1 when differences exist.
+
+Examples:
+  # compare all resources
+  vaxctl compare --from lab --to prod
+
+  # compare actions and rules and show the differences
+  vaxctl compare --from lab --to prod --kinds action,rule --diff`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		differences, err := model.Compare(migrateOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		if differences {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+	compareCmd.Flags().StringVar(&migrateOptions.From, "from", "", "context (or URL) of the source server")
+	compareCmd.Flags().StringVar(&migrateOptions.To, "to", "", "context (or URL) of the target server")
+	compareCmd.Flags().StringSliceVar(&migrateOptions.Kinds, "kinds", nil, "kinds to compare (cred, device, action & rule, default is all)")
+	compareCmd.Flags().StringSliceVar(&migrateOptions.Names, "names", nil, "names of the resources to compare (default is all)")
+	compareCmd.Flags().BoolVar(&migrateOptions.Diff, "diff", false, "print a unified diff of the resources that differ")
+	compareCmd.MarkFlagRequired("from")
+	compareCmd.MarkFlagRequired("to")
+}
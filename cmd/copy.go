@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy <action|cred|device|rule> SOURCE NAME",
+	Short: "Copy a resource",
+	Long:  `Copy a resource to a new one, the fields of the given flags are changed in the copy`,
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	rootCmd.AddCommand(copyCmd)
+}
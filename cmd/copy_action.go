@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var copyActionCmd = &cobra.Command{
+	Use:   "action SOURCE NAME",
+	Short: "Copy a action",
+	Long: `Copy a action to a new one.
+
+The fields of the given flags are changed in the copy, they are validated
+against the values known by the server before sending.
+
+Examples:
+  # Copy an action with other data
+  vaxctl copy action power-on power-cycle --data cycle`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: model.GetActionNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.CopyResource("action", args[0], args[1], fieldsFromFlags(cmd))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	copyCmd.AddCommand(copyActionCmd)
+	addActionFieldFlags(copyActionCmd)
+}
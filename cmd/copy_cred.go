@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var copyCredCmd = &cobra.Command{
+	Use:   "cred SOURCE NAME",
+	Short: "Copy a cred",
+	Long: `Copy a cred to a new one.
+
+The fields of the given flags are changed in the copy, they are validated
+against the values known by the server before sending.
+
+Examples:
+  # Copy a cred with another password
+  vaxctl copy cred admin admin-2 --password calvin`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: model.GetCredNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.CopyResource("creds", args[0], args[1], fieldsFromFlags(cmd))
+		if isDefault, _ := cmd.Flags().GetBool("default"); err == nil && isDefault {
+			err = model.SetCredsAsDefault(args[1])
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	copyCmd.AddCommand(copyCredCmd)
+	addCredFieldFlags(copyCredCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var copyDeviceCmd = &cobra.Command{
+	Use:   "device SOURCE UID",
+	Short: "Copy a device",
+	Long: `Copy a device to a new one.
+
+The fields of the given flags are changed in the copy, they are validated
+against the values known by the server before sending.
+
+Examples:
+  # Copy a device, it must have its own IPMI IP
+  vaxctl copy device node-1 node-2 --ipmi-ip 10.0.0.2`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: model.GetDeviceNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.CopyResource("device", args[0], args[1], fieldsFromFlags(cmd))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	copyCmd.AddCommand(copyDeviceCmd)
+	addDeviceFieldFlags(copyDeviceCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var copyRuleCmd = &cobra.Command{
+	Use:   "rule SOURCE NAME",
+	Short: "Copy a rule",
+	Long: `Copy a rule to a new one.
+
+The fields of the given flags are changed in the copy, they are validated
+against the values known by the server before sending.
+
+The copy keeps the screenshot and OCR text of the source rule unless a state
+ID or a screenshot is set.
+
+Examples:
+  # Copy a rule with a new regex, placed after the source rule
+  vaxctl copy rule enter-bios enter-bios-2 --regex "Press DEL" --after enter-bios`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: model.GetRuleNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.CopyResource("rule", args[0], args[1], fieldsFromFlags(cmd))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	copyCmd.AddCommand(copyRuleCmd)
+	addRuleFieldFlags(copyRuleCmd)
+}
@@ -1,16 +1,132 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// resourceFieldFlags maps the flags setting the fields of a resource ('create
+// KIND NAME' and 'set KIND NAME') to the fields, only changed flags are sent.
+var resourceFieldFlags = map[string]string{
+	"type":        "action_type",
+	"data":        "action_data",
+	"username":    "username",
+	"password":    "password",
+	"ipmi-ip":     "ipmi_ip",
+	"model":       "model",
+	"cred":        "creds_name",
+	"zombie":      "zombie",
+	"meta":        "metadata",
+	"regex":       "regex",
+	"actions":     "actions",
+	"after":       "after_rule",
+	"before":      "before_rule",
+	"state-id":    "state_id",
+	"screenshot":  "screenshot",
+	"ignore-case": "ignore_case",
+	"enabled":     "enabled",
+}
+
 var createCmd = &cobra.Command{
-	Use:   "create <action|device|rule|state> -f FILENAME",
-	Short: "Create a resource from file",
-	Long:  `Create a resource from (JSON and YAML formats are accepted)`,
-	Args:  cobra.NoArgs,
+	Use:   "create [action|cred|device|rule|state] -f FILENAME",
+	Short: "Create resources from files",
+	Long: `Create resources from files (JSON and YAML formats are accepted).
+
+Without a sub-command the files may hold multiple '---' separated documents,
+each one selecting its resource with a 'kind' field (cred, device, action, rule,
+state or work). Documents are created after the resources they reference in
+the same bundle.
+
+Examples:
+  # create all documents in a file
+  vaxctl create -f fleet.yaml
+
+  # create all YAML/JSON files in a directory and its sub-directories
+  vaxctl create -f manifests/ -R
+
+  # create documents from stdin
+  cat fleet.yaml | vaxctl create -f -
+
+  # create a single resource from flags
+  vaxctl create action power-on --type power --data on`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(filenames) == 0 {
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.CreateManifests(manifestSource())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
 }
 
 func init() {
 	rootCmd.AddCommand(createCmd)
+	addManifestFlags(createCmd, "files, directories, overlays or '-' (stdin) to create the resources from")
+}
+
+// fieldsFromFlags returns the fields set by the changed resource field flags.
+func fieldsFromFlags(cmd *cobra.Command) map[string]interface{} {
+	fields := map[string]interface{}{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		field, ok := resourceFieldFlags[flag.Name]
+		if !ok {
+			return
+		}
+		switch flag.Value.Type() {
+		case "bool":
+			fields[field], _ = cmd.Flags().GetBool(flag.Name)
+		case "int":
+			fields[field], _ = cmd.Flags().GetInt(flag.Name)
+		case "stringSlice":
+			values, _ := cmd.Flags().GetStringSlice(flag.Name)
+			fields[field] = model.JoinMacroReferences(values)
+		case "stringToString":
+			fields[field], _ = cmd.Flags().GetStringToString(flag.Name)
+		default:
+			fields[field] = flag.Value.String()
+		}
+	})
+	return fields
+}
+
+func addActionFieldFlags(cmd *cobra.Command) {
+	cmd.Flags().String("type", "", "action type (see 'create action -I')")
+	cmd.Flags().String("data", "", "action data (based on type, see 'create action -I -v')")
+}
+
+func addCredFieldFlags(cmd *cobra.Command) {
+	cmd.Flags().String("username", "", "username of the credentials")
+	cmd.Flags().String("password", "", "password of the credentials")
+	cmd.Flags().Bool("default", false, "set the credentials as default")
+}
+
+func addDeviceFieldFlags(cmd *cobra.Command) {
+	cmd.Flags().String("ipmi-ip", "", "IPMI IP of the device")
+	cmd.Flags().String("model", "", "device HW model")
+	cmd.Flags().String("cred", "", "credential name to use ('default' for the default one)")
+	cmd.Flags().Bool("zombie", false, "whether the device is a zombie")
+	cmd.Flags().StringToString("meta", nil, "metadata of the device (KEY=VALUE, merged with the existing keys)")
+	cmd.RegisterFlagCompletionFunc("cred", model.GetCredNamesForCompletion)
+}
+
+func addRuleFieldFlags(cmd *cobra.Command) {
+	cmd.Flags().String("regex", "", "regex to use for matching states")
+	cmd.Flags().StringSlice("actions", nil, "comma separated list of actions (or macro references)")
+	cmd.Flags().String("after", "", "name of the rule to place it after")
+	cmd.Flags().String("before", "", "name of the rule to place it before")
+	cmd.Flags().Int("state-id", 0, "ID of the state to take the screenshot from")
+	cmd.Flags().String("screenshot", "", "image file of the screenshot")
+	cmd.Flags().Bool("ignore-case", true, "whether the regex ignores case")
+	cmd.Flags().Bool("enabled", true, "whether the rule is enabled")
+	cmd.RegisterFlagCompletionFunc("actions", model.GetActionNamesForCompletion)
+	cmd.RegisterFlagCompletionFunc("after", model.GetRuleNamesForCompletion)
+	cmd.RegisterFlagCompletionFunc("before", model.GetRuleNamesForCompletion)
 }
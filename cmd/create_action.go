@@ -12,11 +12,12 @@ import (
 var listTypes, verbose bool
 
 var createActionCmd = &cobra.Command{
-	Use:   "action",
-	Short: "Create action from file",
-	Long: `Create a new action from file.
+	Use:   "action [NAME --type TYPE --data DATA]",
+	Short: "Create action from file or flags",
+	Long: `Create a new action from file or flags.
 
-JSON and YAML formats are accepted.
+JSON and YAML formats are accepted, the flags are validated against the
+action types, power options and special keys of the server before sending.
   
 Examples:
   # create action from json
@@ -25,9 +26,12 @@ Examples:
   # create action from yaml
   vaxctl create action -f action.yaml
 
+  # create action from flags
+  vaxctl create action power-on --type power --data on
+
   # create action in interactive mode
   vaxctl create action -i`,
-	Args: cobra.NoArgs,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 		if listTypes {
@@ -37,12 +41,14 @@ Examples:
 				err = model.ListActionTypes()
 			}
 		} else {
-			if interactive {
+			if len(args) == 1 {
+				err = model.CreateResourceFromFields("action", args[0], fieldsFromFlags(cmd))
+			} else if interactive {
 				err = tui.CreateAction()
 			} else if filename != "" {
 				err = model.CreateResource("action", filename)
 			} else {
-				fmt.Println("You must set either a filename OR enable interactive mode")
+				fmt.Println("You must set either a name with flags OR a filename OR enable interactive mode")
 				cmd.Help()
 				os.Exit(2)
 			}
@@ -60,4 +66,5 @@ func init() {
 	createActionCmd.Flags().BoolVarP(&listTypes, "list-types", "I", false, "list available action types")
 	createActionCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show detailed data for each action type")
 	createActionCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "open interactive mode")
+	addActionFieldFlags(createActionCmd)
 }
@@ -10,9 +10,9 @@ import (
 )
 
 var createCredCmd = &cobra.Command{
-	Use:   "cred",
-	Short: "Create cred from file",
-	Long: `Create a new cred from file.
+	Use:   "cred [NAME --username USERNAME --password PASSWORD]",
+	Short: "Create cred from file or flags",
+	Long: `Create a new cred from file or flags.
 
 JSON and YAML formats are accepted.
   
@@ -21,16 +21,24 @@ Examples:
   vaxctl create cred -f cred.json
     
   # create cred from yaml
-  vaxctl create cred -f cred.yaml`,
-	Args: cobra.NoArgs,
+  vaxctl create cred -f cred.yaml
+
+  # create default cred from flags
+  vaxctl create cred admin --username root --password calvin --default`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
-		if interactive {
+		if len(args) == 1 {
+			err = model.CreateResourceFromFields("creds", args[0], fieldsFromFlags(cmd))
+			if isDefault, _ := cmd.Flags().GetBool("default"); err == nil && isDefault {
+				err = model.SetCredsAsDefault(args[0])
+			}
+		} else if interactive {
 			err = tui.CreateCred()
 		} else if filename != "" {
 			err = model.CreateResource("creds", filename)
 		} else {
-			fmt.Println("You must set either a filename OR enable interactive mode")
+			fmt.Println("You must set either a name with flags OR a filename OR enable interactive mode")
 			cmd.Help()
 			os.Exit(2)
 		}
@@ -45,4 +53,5 @@ func init() {
 	createCmd.AddCommand(createCredCmd)
 	createCredCmd.Flags().StringVarP(&filename, "filename", "f", "", "filename to use to create the resource")
 	createCredCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "open interactive mode")
+	addCredFieldFlags(createCredCmd)
 }
@@ -10,9 +10,9 @@ import (
 )
 
 var createDeviceCmd = &cobra.Command{
-	Use:   "device",
-	Short: "Create device from file",
-	Long: `Create a new device from file.
+	Use:   "device [UID --ipmi-ip IP --model MODEL]",
+	Short: "Create device from file or flags",
+	Long: `Create a new device from file or flags.
 
 JSON and YAML formats are accepted.
   
@@ -23,17 +23,22 @@ Examples:
   # create device from yaml
   vaxctl create device -f device.yaml
 
+  # create device from flags
+  vaxctl create device node-1 --ipmi-ip 10.0.0.1 --model R640 --cred admin --meta rack=A1
+
   # create device in interactive mode
   vaxctl create device -i`,
-	Args: cobra.NoArgs,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
-		if interactive {
+		if len(args) == 1 {
+			err = model.CreateResourceFromFields("device", args[0], fieldsFromFlags(cmd))
+		} else if interactive {
 			err = tui.CreateDevice()
 		} else if filename != "" {
 			err = model.CreateResource("device", filename)
 		} else {
-			fmt.Println("You must set either a filename OR enable interactive mode")
+			fmt.Println("You must set either a UID with flags OR a filename OR enable interactive mode")
 			cmd.Help()
 			os.Exit(2)
 		}
@@ -48,4 +53,5 @@ func init() {
 	createCmd.AddCommand(createDeviceCmd)
 	createDeviceCmd.Flags().StringVarP(&filename, "filename", "f", "", "filename to use to create the resource")
 	createDeviceCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "open interactive mode")
+	addDeviceFieldFlags(createDeviceCmd)
 }
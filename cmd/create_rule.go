@@ -11,22 +11,28 @@ import (
 )
 
 var createRuleCmd = &cobra.Command{
-	Use:   "rule",
-	Short: "Create rule from file",
-	Long: `Create a new rule from file.
+	Use:   "rule [NAME --regex REGEX --actions ACTIONS --state-id ID]",
+	Short: "Create rule from file or flags",
+	Long: `Create a new rule from file or flags.
 
-JSON and YAML formats are accepted.
+JSON and YAML formats are accepted, the macro references of the actions
+(macro:NAME or macro:NAME(PARAM=VALUE,...)) are expanded.
   
 Examples:
   # create rule from yaml
   vaxctl create rule -f rule.yaml
 
+  # create rule from flags, placed after another rule
+  vaxctl create rule enter-bios --regex "Press F2" --actions press-f2,wait --state-id 12 --after boot-menu
+
   # create rule from state in interactive mode
   vaxctl create rule -i STATE_ID`,
-	Args: cobra.NoArgs,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
-		if name != "" {
+		if len(args) == 1 {
+			err = model.CreateResourceFromFields("rule", args[0], fieldsFromFlags(cmd))
+		} else if name != "" {
 			_, err = model.GetStates(name, "", "", "")
 			if err != nil {
 				fmt.Println(err)
@@ -55,7 +61,7 @@ Examples:
 		} else if filename != "" {
 			err = model.CreateResource("rule", filename)
 		} else {
-			fmt.Println("You must set either a filename OR a state ID OR a device UID")
+			fmt.Println("You must set either a name with flags OR a filename OR a state ID OR a device UID")
 			cmd.Help()
 			os.Exit(2)
 		}
@@ -73,4 +79,5 @@ func init() {
 	createRuleCmd.Flags().StringVarP(&output, "device", "d", "", "device UID of the open state (for interactive mode)")
 	createRuleCmd.RegisterFlagCompletionFunc("id", model.GetStateIdsForCompletion)
 	createRuleCmd.RegisterFlagCompletionFunc("device", model.GetDeviceNamesForCompletion)
+	addRuleFieldFlags(createRuleCmd)
 }
@@ -1,18 +1,45 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
 	"github.com/spf13/cobra"
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete <action|device|rule|state> [flags]",
-	Short: "Delete a resource",
+	Use:   "delete [action|cred|device|rule] [flags]",
+	Short: "Delete resources",
 	Long: `Delete resources by filenames or names (JSON and YAML formats are accepted).
 
-Only one type of the arguments may be specified: filenames or names.`,
+Only one type of the arguments may be specified: filenames or names.
+
+Without a sub-command the files may hold multiple '---' separated documents,
+each one selecting its resource with a 'kind' field (cred, device, action or rule).
+Documents are deleted in the reverse dependency order.
+
+Examples:
+  # delete all documents in a file
+  vaxctl delete -f fleet.yaml
+
+  # delete all YAML/JSON files in a directory and its sub-directories
+  vaxctl delete -f manifests/ -R`,
 	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(filenames) == 0 {
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.DeleteManifests(manifestSource())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	addManifestFlags(deleteCmd, "files, directories, overlays or '-' (stdin) to delete the resources from")
 }
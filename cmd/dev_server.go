@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"vaxctl/fakeserver"
+
+	"github.com/spf13/cobra"
+)
+
+var listenAddress string
+
+var devServerCmd = &cobra.Command{
+	Use:   "dev-server",
+	Short: "Run an in-memory vaxiin server",
+	Long: `Run an in-memory vaxiin server for local development and tests.
+
+The server implements the API routes used by vaxctl and keeps all data in memory.
+Fixtures (creds, devices, actions, rules and states) can be loaded on startup,
+states may include 'ocr_text' since no OCR is done by this server.
+
+Examples:
+  # run an empty server on the default port
+  vaxctl dev-server
+
+  # run a server seeded from a fixtures file
+  vaxctl dev-server -l 127.0.0.1:5050 -f fixtures.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fixtures, err := fakeserver.LoadFixtures(filename)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		handler, err := fakeserver.NewHandler(fixtures)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Serving vaxiin API on http://%s\n", listenAddress)
+		err = http.ListenAndServe(listenAddress, handler)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(devServerCmd)
+	devServerCmd.Flags().StringVarP(&listenAddress, "listen", "l", "127.0.0.1:5000", "address to listen on")
+	devServerCmd.Flags().StringVarP(&filename, "filename", "f", "", "fixtures file to seed the server with (JSON and YAML formats are accepted)")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff -f FILENAME",
+	Short: "Diff resources in files against the live server",
+	Long: `Diff resources in files against the live server (JSON and YAML formats are accepted).
+
+The files hold '---' separated documents with a 'kind' field (cred, device,
+action or rule), each one is compared to the live resource after removing the
+server managed fields and a unified diff is printed for the ones that differ.
+
+The exit code is 0 when there are no differences, 1 when differences exist and
+2 when any of the documents failed.
+
+Examples:
+  # diff all documents in a file
+  vaxctl diff -f fleet.yaml
+
+  # diff all YAML/JSON files in a directory and its sub-directories
+  vaxctl diff -f manifests/ -R`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		differences, err := model.DiffManifests(manifestSource())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		if differences {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	addManifestFlags(diffCmd, "files, directories, overlays or '-' (stdin) to diff")
+	diffCmd.MarkFlagRequired("filename")
+}
@@ -1,16 +1,43 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 )
 
 var editCmd = &cobra.Command{
-	Use:   "edit <action|device|rule> -n NAME",
+	Use:   "edit <action|cred|device|rule|macro> NAME...",
 	Short: "Edit a resource",
-	Long:  `Edit a resource (open an interactive shell to edit a resource from the server)`,
-	Args:  cobra.NoArgs,
+	Long: `Edit a resource (open an interactive shell to edit a resource from the server)
+
+With '--editor' the resources are opened as YAML in $EDITOR instead, the file
+is re-opened with the errors as comments when invalid and only the changed
+resources are sent.`,
+	Args: cobra.NoArgs,
 }
 
 func init() {
 	rootCmd.AddCommand(editCmd)
 }
+
+// editNames returns the names to edit (the arguments and '-n'), only one
+// can be edited in interactive mode.
+func editNames(cmd *cobra.Command, args []string) []string {
+	names := args
+	if name != "" {
+		names = append([]string{name}, args...)
+	}
+	if len(names) == 0 {
+		fmt.Println("You must set the name of the resource to edit")
+		cmd.Usage()
+		os.Exit(2)
+	}
+	if useEditor, _ := cmd.Flags().GetBool("editor"); len(names) > 1 && !useEditor {
+		fmt.Println("Only one resource can be edited in interactive mode, use '--editor' to edit many")
+		cmd.Usage()
+		os.Exit(2)
+	}
+	return names
+}
@@ -10,7 +10,7 @@ import (
 )
 
 var editActionCmd = &cobra.Command{
-	Use:   "action",
+	Use:   "action [NAME...]",
 	Short: "edit action from server",
 	Long: `edit an action in interactive mode.
 
@@ -18,9 +18,23 @@ Can be exported to JSON and YAML formats.
   
 Examples:
   # edit action from server
-  vaxctl edit action -n ACTION_NAME`,
-	Args: cobra.NoArgs,
+  vaxctl edit action -n ACTION_NAME
+
+  # edit actions as YAML in $EDITOR
+  vaxctl edit action ACTION_NAME OTHER_ACTION_NAME --editor`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: model.GetActionNamesForCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
+		names := editNames(cmd, args)
+		if useEditor, _ := cmd.Flags().GetBool("editor"); useEditor {
+			err := model.EditResources("action", names)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+		name = names[0]
 		_, err := model.GetActions(name)
 		if err != nil {
 			fmt.Println(err)
@@ -37,6 +51,6 @@ Examples:
 func init() {
 	editCmd.AddCommand(editActionCmd)
 	editActionCmd.Flags().StringVarP(&name, "name", "n", "", "name of the action to edit")
-	editActionCmd.MarkFlagRequired("name")
+	editActionCmd.Flags().Bool("editor", false, "edit as YAML in $EDITOR instead of interactive mode")
 	editActionCmd.RegisterFlagCompletionFunc("name", model.GetActionNamesForCompletion)
 }
@@ -10,7 +10,7 @@ import (
 )
 
 var editCredCmd = &cobra.Command{
-	Use:   "cred",
+	Use:   "cred [NAME...]",
 	Short: "edit cred from server",
 	Long: `edit an cred in interactive mode.
 
@@ -18,9 +18,23 @@ Can be exported to JSON and YAML formats.
   
 Examples:
   # edit cred from server
-  vaxctl edit cred -n CRED_NAME`,
-	Args: cobra.NoArgs,
+  vaxctl edit cred -n CRED_NAME
+
+  # edit creds as YAML in $EDITOR
+  vaxctl edit cred CRED_NAME OTHER_CRED_NAME --editor`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: model.GetCredNamesForCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
+		names := editNames(cmd, args)
+		if useEditor, _ := cmd.Flags().GetBool("editor"); useEditor {
+			err := model.EditResources("creds", names)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+		name = names[0]
 		_, err := model.GetCreds(name)
 		if err != nil {
 			fmt.Println(err)
@@ -37,6 +51,6 @@ Examples:
 func init() {
 	editCmd.AddCommand(editCredCmd)
 	editCredCmd.Flags().StringVarP(&name, "name", "n", "", "name of the credential to edit")
-	editCredCmd.MarkFlagRequired("name")
+	editCredCmd.Flags().Bool("editor", false, "edit as YAML in $EDITOR instead of interactive mode")
 	editCredCmd.RegisterFlagCompletionFunc("name", model.GetCredNamesForCompletion)
 }
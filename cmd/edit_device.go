@@ -10,7 +10,7 @@ import (
 )
 
 var editDeviceCmd = &cobra.Command{
-	Use:   "device",
+	Use:   "device [UID...]",
 	Short: "edit device from server",
 	Long: `edit a device in interactive mode.
 
@@ -18,9 +18,23 @@ Can be exported to JSON and YAML formats.
   
 Examples:
   # edit device from server
-  vaxctl edit device -n DEVICE_UID`,
-	Args: cobra.NoArgs,
+  vaxctl edit device -n DEVICE_UID
+
+  # edit devices as YAML in $EDITOR
+  vaxctl edit device DEVICE_UID OTHER_DEVICE_UID --editor`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: model.GetDeviceNamesForCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
+		names := editNames(cmd, args)
+		if useEditor, _ := cmd.Flags().GetBool("editor"); useEditor {
+			err := model.EditResources("device", names)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+		name = names[0]
 		_, err := model.GetDevices(name)
 		if err != nil {
 			fmt.Println(err)
@@ -37,6 +51,6 @@ Examples:
 func init() {
 	editCmd.AddCommand(editDeviceCmd)
 	editDeviceCmd.Flags().StringVarP(&name, "name", "n", "", "UID of the device to edit")
-	editDeviceCmd.MarkFlagRequired("name")
+	editDeviceCmd.Flags().Bool("editor", false, "edit as YAML in $EDITOR instead of interactive mode")
 	editDeviceCmd.RegisterFlagCompletionFunc("name", model.GetDeviceNamesForCompletion)
 }
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var editMacroCmd = &cobra.Command{
+	Use:   "macro NAME...",
+	Short: "edit macro from the macro store",
+	Long: `edit macros as YAML in $EDITOR.
+
+Macros are client-side, they are always edited in $EDITOR.
+  
+Examples:
+  # edit macro
+  vaxctl edit macro MACRO_NAME`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: model.GetMacroNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.EditResources("macro", args)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	editCmd.AddCommand(editMacroCmd)
+	editMacroCmd.Flags().Bool("editor", true, "edit as YAML in $EDITOR (always set for macros)")
+	editMacroCmd.Flags().MarkHidden("editor")
+}
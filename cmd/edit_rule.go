@@ -10,7 +10,7 @@ import (
 )
 
 var editRuleCmd = &cobra.Command{
-	Use:   "rule",
+	Use:   "rule [NAME...]",
 	Short: "edit rule from server",
 	Long: `edit a rule in interactive mode.
 
@@ -18,9 +18,23 @@ Can be exported to JSON and YAML formats.
   
 Examples:
   # edit rule from server
-  vaxctl edit rule -n RULE_NAME`,
-	Args: cobra.NoArgs,
+  vaxctl edit rule -n RULE_NAME
+
+  # edit rules as YAML in $EDITOR
+  vaxctl edit rule RULE_NAME OTHER_RULE_NAME --editor`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: model.GetRuleNamesForCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
+		names := editNames(cmd, args)
+		if useEditor, _ := cmd.Flags().GetBool("editor"); useEditor {
+			err := model.EditResources("rule", names)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+		name = names[0]
 		_, err := model.GetRules(name)
 		if err != nil {
 			fmt.Println(err)
@@ -37,6 +51,6 @@ Examples:
 func init() {
 	editCmd.AddCommand(editRuleCmd)
 	editRuleCmd.Flags().StringVarP(&name, "name", "n", "", "name of the rule to edit")
-	editRuleCmd.MarkFlagRequired("name")
+	editRuleCmd.Flags().Bool("editor", false, "edit as YAML in $EDITOR instead of interactive mode")
 	editRuleCmd.RegisterFlagCompletionFunc("name", model.GetRuleNamesForCompletion)
 }
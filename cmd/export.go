@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var exportOptions model.ExportOptions
+
+var exportCmd = &cobra.Command{
+	Use:   "export --dir DIRECTORY",
+	Short: "Back up all resources to a directory",
+	Long: `Back up all creds, devices, actions and rules to a directory.
+
+Each kind is written to its own multi-document YAML file (rules in their order)
+that can be applied with 'vaxctl apply -f', together with a 'manifest.yaml'
+holding the counts and the export time. The creds file holds the passwords and
+is only readable by its owner.
+
+Examples:
+  # back up the server
+  vaxctl export --dir backup/
+
+  # back up the server including the screenshots and OCR text of the states
+  vaxctl export --dir backup/ --include-states`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.Export(exportOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVar(&exportOptions.Dir, "dir", "", "directory to write the backup to")
+	exportCmd.Flags().BoolVar(&exportOptions.IncludeStates, "include-states", false, "also write the screenshot and OCR text of every state (for reference, states can't be imported)")
+	exportCmd.MarkFlagRequired("dir")
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var offlineSchema bool
+
+var generateSchemaCmd = &cobra.Command{
+	Use:   "schema KIND",
+	Short: "Generate the JSON Schema of a resource",
+	Long: `Generate the JSON Schema of a resource (cred, device, action, rule, state, work or macro).
+
+The schema can be used by editors for validation and autocomplete of resource
+files, the names of the existing resources (actions, rules, creds) are listed
+as allowed values unless '--offline' is set.
+
+Examples:
+  # Generate the rule schema and print to screen
+  vaxctl generate schema rule
+
+  # Generate the device schema in a file without contacting the server
+  vaxctl generate schema device --offline -f device.schema.json`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"cred", "device", "action", "rule", "state", "work", "macro"},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.GenerateSchema(args[0], filename, offlineSchema)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(generateSchemaCmd)
+	generateSchemaCmd.Flags().BoolVar(&offlineSchema, "offline", false, "don't list the names of the existing resources")
+}
@@ -20,10 +20,18 @@ Examples:
   vaxctl get action
 		
   # Get action by name as yaml
-  vaxctl get action -n ACTION_NAME -o yaml`,
+  vaxctl get action -n ACTION_NAME -o yaml
+
+  # Export all actions as re-appliable files, one per resource
+  vaxctl get action --output-dir actions/`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.PrintActions(name, output)
+		var err error
+		if exportResources || outputDir != "" {
+			err = model.ExportActions(name, output, outputDir)
+		} else {
+			err = model.PrintActions(name, output)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -36,4 +44,6 @@ func init() {
 	getActionCmd.Flags().StringVarP(&name, "name", "n", "", "name of resource (if not set all are returned)")
 	getActionCmd.RegisterFlagCompletionFunc("name", model.GetActionNamesForCompletion)
 	getActionCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+	getActionCmd.Flags().BoolVar(&exportResources, "export", false, "print re-appliable documents without the server managed fields (yaml unless '-o json' is set)")
+	getActionCmd.Flags().StringVar(&outputDir, "output-dir", "", "write each resource to its own file in the directory (implies --export)")
 }
@@ -20,10 +20,18 @@ Examples:
   vaxctl get cred
 		
   # Get cred by name as yaml
-  vaxctl get cred -n NAME -o yaml`,
+  vaxctl get cred -n NAME -o yaml
+
+  # Export all creds as re-appliable files, one per resource
+  vaxctl get cred --output-dir creds/`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.PrintCreds(name, output)
+		var err error
+		if exportResources || outputDir != "" {
+			err = model.ExportCreds(name, output, outputDir)
+		} else {
+			err = model.PrintCreds(name, output)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -36,4 +44,6 @@ func init() {
 	getCredCmd.Flags().StringVarP(&name, "name", "n", "", "name of resource (if not set all are returned)")
 	getCredCmd.RegisterFlagCompletionFunc("name", model.GetCredNamesForCompletion)
 	getCredCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+	getCredCmd.Flags().BoolVar(&exportResources, "export", false, "print re-appliable documents without the server managed fields (yaml unless '-o json' is set)")
+	getCredCmd.Flags().StringVar(&outputDir, "output-dir", "", "write each resource to its own file in the directory (implies --export)")
 }
@@ -20,10 +20,18 @@ Examples:
   vaxctl get device
 		
   # Get device by uid as yaml
-  vaxctl get device -n UID -o yaml`,
+  vaxctl get device -n UID -o yaml
+
+  # Export all devices as re-appliable files, one per resource
+  vaxctl get device --output-dir devices/`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.PrintDevices(name, output)
+		var err error
+		if exportResources || outputDir != "" {
+			err = model.ExportDevices(name, output, outputDir)
+		} else {
+			err = model.PrintDevices(name, output)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -36,4 +44,6 @@ func init() {
 	getDeviceCmd.Flags().StringVarP(&name, "uid", "n", "", "uid of resource (if not set all are returned)")
 	getDeviceCmd.RegisterFlagCompletionFunc("uid", model.GetDeviceNamesForCompletion)
 	getDeviceCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+	getDeviceCmd.Flags().BoolVar(&exportResources, "export", false, "print re-appliable documents without the server managed fields (yaml unless '-o json' is set)")
+	getDeviceCmd.Flags().StringVar(&outputDir, "output-dir", "", "write each resource to its own file in the directory (implies --export)")
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var expandedRules bool
+
 var getRuleCmd = &cobra.Command{
 	Use:   "rule",
 	Short: "Get one or many rules",
@@ -20,10 +22,23 @@ Examples:
   vaxctl get rule
 		
   # Get rule by name as yaml
-  vaxctl get rule -n RULE_NAME -o yaml`,
+  vaxctl get rule -n RULE_NAME -o yaml
+
+  # Export all rules as re-appliable files, one per resource
+  vaxctl get rule --output-dir rules/
+
+  # List the actions of a rule in order, with the macro references they were expanded from
+  vaxctl get rule -n RULE_NAME --expanded`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.PrintRules(name, verbose, output)
+		var err error
+		if expandedRules {
+			err = model.PrintExpandedRules(name, output)
+		} else if exportResources || outputDir != "" {
+			err = model.ExportRules(name, verbose, output, outputDir)
+		} else {
+			err = model.PrintRules(name, verbose, output)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -34,7 +49,10 @@ Examples:
 func init() {
 	getCmd.AddCommand(getRuleCmd)
 	getRuleCmd.Flags().StringVarP(&name, "name", "n", "", "name of resource (if not set all are returned)")
-	getRuleCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "include screenshot & ocr_text values in yaml/json")
+	getRuleCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "include screenshot & ocr_text values in yaml/json ('--export' always includes the screenshot)")
 	getRuleCmd.RegisterFlagCompletionFunc("name", model.GetRuleNamesForCompletion)
 	getRuleCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+	getRuleCmd.Flags().BoolVar(&exportResources, "export", false, "print re-appliable documents without the server managed fields (yaml unless '-o json' is set)")
+	getRuleCmd.Flags().BoolVar(&expandedRules, "expanded", false, "list the actions of the rules in order, with the macro references they were expanded from")
+	getRuleCmd.Flags().StringVar(&outputDir, "output-dir", "", "write each resource to its own file in the directory (implies --export)")
 }
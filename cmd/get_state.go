@@ -26,10 +26,18 @@ Examples:
   vaxctl get state -t open
 	
   # Get resolved states as yaml
-  vaxctl get state -t resolved -o yaml`,
+  vaxctl get state -t resolved -o yaml
+
+  # Export all states as re-appliable files, one per resource
+  vaxctl get state --output-dir states/`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.PrintStates(name, filename, deviceUid, regex, verbose, output)
+		var err error
+		if exportResources || outputDir != "" {
+			err = model.ExportStates(name, filename, deviceUid, regex, output, outputDir)
+		} else {
+			err = model.PrintStates(name, filename, deviceUid, regex, verbose, output)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -50,4 +58,6 @@ func init() {
 	getStateCmd.Flags().StringVarP(&regex, "regex", "r", "", "get states by matching regex (if not set all are returned)")
 	getStateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show full OCR text")
 	getStateCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+	getStateCmd.Flags().BoolVar(&exportResources, "export", false, "print re-appliable documents without the server managed fields (yaml unless '-o json' is set)")
+	getStateCmd.Flags().StringVar(&outputDir, "output-dir", "", "write each resource to its own file in the directory (implies --export)")
 }
@@ -23,10 +23,18 @@ Examples:
   vaxctl get work
 		
   # Get latest work with details by device
-  vaxctl get work -d DEVICE_UID -v -l`,
+  vaxctl get work -d DEVICE_UID -v -l
+
+  # Export all works as re-appliable files, one per resource
+  vaxctl get work --output-dir works/`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.GetWorks(filename, name, showDetails, latest, output)
+		var err error
+		if exportResources || outputDir != "" {
+			err = model.ExportWorks(filename, name, latest, output, outputDir)
+		} else {
+			err = model.GetWorks(filename, name, showDetails, latest, output)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -43,4 +51,6 @@ func init() {
 	getWorkCmd.Flags().StringVarP(&filename, "id", "i", "", "id of resource (if not set all are returned)")
 	getWorkCmd.RegisterFlagCompletionFunc("id", model.GetWorkIdsForCompletion)
 	getWorkCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+	getWorkCmd.Flags().BoolVar(&exportResources, "export", false, "print re-appliable documents without the server managed fields (yaml unless '-o json' is set)")
+	getWorkCmd.Flags().StringVar(&outputDir, "output-dir", "", "write each resource to its own file in the directory (implies --export)")
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var importDir string
+
+var importCmd = &cobra.Command{
+	Use:   "import --dir DIRECTORY",
+	Short: "Restore resources from a backup directory",
+	Long: `Restore resources from a directory written by 'vaxctl export'.
+
+The server may be empty or hold resources already, the resources are
+created/updated in dependency order and the rules are ordered like they were
+exported.
+
+Examples:
+  # restore a backup
+  vaxctl import --dir backup/`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if importDir == "" {
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.Import(importDir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&importDir, "dir", "", "backup directory to restore")
+}
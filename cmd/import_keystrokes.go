@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var importKeystrokesOptions model.ImportKeystrokesOptions
+
+var importKeystrokesCmd = &cobra.Command{
+	Use:   "keystrokes (--asciinema FILE | --script FILE) -n NAME",
+	Short: "Convert a recorded session or a script to keystroke actions",
+	Long: `Convert the input of a recorded terminal session or a script to keystroke actions.
+
+Asciinema recordings (v2 and v3) must hold the input events ('asciinema rec
+--stdin'), pauses of at least '--pause' become sleep actions. Scripts can use
+the expect 'send' and 'sleep' commands or the xdotool 'type', 'key' and
+'sleep' commands (with or without the 'xdotool' prefix), other expect commands
+are ignored.
+
+Escape sequences and control characters are converted to special keys (e.g.
+'\e[12~' to 'Keys.F2', '\r' to 'Keys.Enter', '\x03' to 'Keys.Control+c'). The
+actions are written as documents ready for 'vaxctl apply -f', preceded by a
+comment with their order for a rule.
+
+Script example:
+  send "\033\[12~"
+  sleep 5
+  xdotool key Down Down Return
+  type "admin\r"
+
+Examples:
+  # convert a recording and print the actions
+  vaxctl import keystrokes --asciinema bios.cast -n enter-bios
+
+  # convert a script to a file
+  vaxctl import keystrokes --script pxe-boot.exp -n pxe-boot -f pxe-boot.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (importKeystrokesOptions.Asciinema == "") == (importKeystrokesOptions.Script == "") {
+			fmt.Println("exactly one of '--asciinema' and '--script' must be set")
+			os.Exit(2)
+		}
+		err := model.ImportKeystrokes(importKeystrokesOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	importCmd.AddCommand(importKeystrokesCmd)
+	importKeystrokesCmd.Flags().StringVar(&importKeystrokesOptions.Asciinema, "asciinema", "", "asciinema recording to convert")
+	importKeystrokesCmd.Flags().StringVar(&importKeystrokesOptions.Script, "script", "", "expect or xdotool script to convert")
+	importKeystrokesCmd.Flags().StringVarP(&importKeystrokesOptions.Name, "name", "n", "", "name of the action (numbered when there are several)")
+	importKeystrokesCmd.MarkFlagRequired("name")
+	importKeystrokesCmd.Flags().DurationVar(&importKeystrokesOptions.Pause, "pause", 2*time.Second, "shortest pause in a recording that becomes a sleep action")
+	importKeystrokesCmd.Flags().StringVarP(&importKeystrokesOptions.Filename, "filename", "f", "", "output file (if not set will print to stdout)")
+}
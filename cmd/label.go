@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "Update the metadata of a resource",
+	Long:  `Set and remove metadata keys of a resource`,
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	rootCmd.AddCommand(labelCmd)
+}
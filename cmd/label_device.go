@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var labelDeviceCmd = &cobra.Command{
+	Use:   "device UID KEY=VALUE... KEY-...",
+	Short: "Update the metadata of a device",
+	Long: `Update the metadata of a device.
+
+Set metadata keys with KEY=VALUE and remove them with KEY-, the other keys are
+kept
+
+Examples:
+  # Set the rack and remove the row of a device
+  vaxctl label device DEVICE_UID rack=B2 row-`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: model.GetDeviceNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.LabelDevice(args[0], args[1:])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	labelCmd.AddCommand(labelDeviceCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --from CONTEXT --to CONTEXT",
+	Short: "Copy resources from one server to another",
+	Long: `Copy resources from one server to another (see 'vaxctl compare --help' for contexts).
+
+Resources are created/updated on the target in dependency order, the creds of
+devices may be renamed with '--cred-map' and the migrated rules are placed like
+they are ordered on the source server.
+
+The plan is printed first and is only carried out with '--yes'.
+
+Examples:
+  # show what would be migrated
+  vaxctl migrate --from lab --to prod --kinds action,rule
+
+  # migrate a device using another cred on the target
+  vaxctl migrate --from lab --to prod --kinds device --names server-1 --cred-map lab-admin=prod-admin --yes`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.Migrate(migrateOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringVar(&migrateOptions.From, "from", "", "context (or URL) of the source server")
+	migrateCmd.Flags().StringVar(&migrateOptions.To, "to", "", "context (or URL) of the target server")
+	migrateCmd.Flags().StringSliceVar(&migrateOptions.Kinds, "kinds", nil, "kinds to migrate (cred, device, action & rule, default is all)")
+	migrateCmd.Flags().StringSliceVar(&migrateOptions.Names, "names", nil, "names of the resources to migrate (default is all)")
+	migrateCmd.Flags().StringToStringVar(&migrateOptions.CredMap, "cred-map", nil, "rename creds on the target (SOURCE=TARGET)")
+	migrateCmd.Flags().BoolVar(&migrateOptions.Yes, "yes", false, "carry out the plan")
+	migrateCmd.MarkFlagRequired("from")
+	migrateCmd.MarkFlagRequired("to")
+}
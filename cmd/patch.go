@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var patchType, patchData, patchFile string
+
+var patchCmd = &cobra.Command{
+	Use:   "patch KIND NAME -p PATCH",
+	Short: "Patch fields of a resource",
+	Long: `Patch fields of a resource (cred, device, action, rule or macro).
+
+The patch is applied to the live resource and the result is sent back, it is
+either a JSON merge patch (RFC 7386, the default) where null removes a key, or
+a JSON patch (RFC 6902) listing operations. JSON and YAML formats are accepted.
+
+Examples:
+  # change a metadata key of a device and remove another one
+  vaxctl patch device DEVICE_UID -p '{"metadata": {"rack": "B2", "row": null}}'
+
+  # append an action to a rule
+  vaxctl patch rule RULE_NAME --type json -p '[{"op": "add", "path": "/actions/-", "value": "wait"}]'
+
+  # patch an action from a file
+  vaxctl patch action ACTION_NAME --patch-file patch.yaml`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: []string{"cred", "device", "action", "rule", "macro"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if (patchData == "") == (patchFile == "") {
+			fmt.Println("You must set either a patch OR a patch file")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		if patchFile != "" {
+			data, err := ioutil.ReadFile(patchFile)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			patchData = string(data)
+		}
+		err := model.PatchResource(args[0], args[1], patchType, patchData)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(patchCmd)
+	patchCmd.Flags().StringVarP(&patchData, "patch", "p", "", "the patch to apply")
+	patchCmd.Flags().StringVar(&patchFile, "patch-file", "", "file holding the patch to apply")
+	patchCmd.Flags().StringVar(&patchType, "type", "merge", "type of the patch. One of: merge|json")
+	patchCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return model.PatchTypes, cobra.ShellCompDirectiveNoFileComp
+	})
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename KIND OLD_NAME NEW_NAME",
+	Short: "Rename a resource and its references",
+	Long: `Rename a resource (cred, action or rule) and its references.
+
+The new resource is created (rules keep their position), the rules listing a
+renamed action, the macros listing it and the devices using a renamed cred
+are updated and the old resource is deleted. When any step fails the previous
+ones are rolled back.
+
+Examples:
+  # rename an action, the rules using it are updated
+  vaxctl rename action press-f1 press-f1-key
+
+  # rename a cred, the devices using it are updated
+  vaxctl rename cred admin bmc-admin`,
+	Args:      cobra.ExactArgs(3),
+	ValidArgs: []string{"cred", "action", "rule"},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.RenameResource(args[0], args[1], args[2])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
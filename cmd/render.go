@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+// renderOutput is apart from the shared output, the subcommands default to
+// a table.
+var renderOutput string
+
+var renderCmd = &cobra.Command{
+	Use:   "render -f FILENAME",
+	Short: "Print the final documents of manifests",
+	Long: `Print the documents of manifests after rendering the templates and resolving the overlays.
+
+The output is what 'vaxctl apply' with the same flags would send, nothing is
+sent to the server. Use 'render action' and 'render rule' to preview the data
+of actions for a device.
+
+Templates fail on a missing value, optional values are read with 'index' and
+given a fallback with 'default': {{ index .Values "rack" | default "A1" }}.
+Templates can't read the environment, pass such values with '--set'.
+
+Examples:
+  # preview templated documents
+  vaxctl render -f devices.yaml --values dc1.yaml --set rack=A1
+
+  # preview an overlay
+  vaxctl render -f overlays/prod/`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(filenames) == 0 {
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.RenderManifests(manifestSource(), renderOutput)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+	addManifestFlags(renderCmd, "files, directories, overlays or '-' (stdin) to render")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "yaml", "output format. One of: json|yaml")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var renderRuleCmd = &cobra.Command{
+	Use:   "rule -n NAME -d DEVICE",
+	Short: "Print the data of an action as it would run on a device",
+	Long: `Print the data of an action with its placeholders resolved for a device.
+
+The device attributes ({device::uid}, {device::ipmi_ip}, {device::model}),
+its metadata ({metadata::KEY}), its cred ({cred::username}, {cred::password})
+and the cred store ({cred_store::CRED_NAME::username}) are resolved the way the
+agent would, unknown metadata keys and missing creds are errors. Passwords are
+masked unless '--show-passwords' is set.
+
+Examples:
+  # render an action for a device
+  vaxctl render action -n ACTION_NAME -d DEVICE_UID
+
+  # render an action with the passwords as yaml
+  vaxctl render action -n ACTION_NAME -d DEVICE_UID --show-passwords -o yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.RenderRule(name, deviceUid, showPasswords, output)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	renderCmd.AddCommand(renderRuleCmd)
+	renderRuleCmd.Flags().StringVarP(&name, "name", "n", "", "name of the rule")
+	renderRuleCmd.RegisterFlagCompletionFunc("name", model.GetRuleNamesForCompletion)
+	renderRuleCmd.MarkFlagRequired("name")
+	renderRuleCmd.Flags().StringVarP(&deviceUid, "device", "d", "", "uid of device")
+	renderRuleCmd.RegisterFlagCompletionFunc("device", model.GetDeviceNamesForCompletion)
+	renderRuleCmd.MarkFlagRequired("device")
+	renderRuleCmd.Flags().BoolVar(&showPasswords, "show-passwords", false, "print the passwords instead of masking them")
+	renderRuleCmd.Flags().StringVarP(&output, "output", "o", "", "output format (default is table). One of: json|yaml")
+}
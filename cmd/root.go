@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"os"
+	"vaxctl/api"
+
 	"github.com/spf13/cobra"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -10,7 +13,13 @@ import (
 var cfgFile string
 var name string
 var filename string
+var filenames []string
+var recursive bool
+var valuesFiles []string
+var setValues []string
 var output string
+var exportResources bool
+var outputDir string
 var deviceUid string
 var regex string
 var interactive bool
@@ -21,6 +30,14 @@ var rootCmd = &cobra.Command{
 	Short:   "vaxctl is used for interacting with the rebooto vaxiin API",
 	Long:    `vaxctl is a CLI that allows creating/deleting/updating objects in the Rebooto vaxiin server`,
 	Version: "DEV-VERSION-0.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		_, err := api.DryRunMode()
+		if err != nil {
+			return err
+		}
+		_, urlFromEnv := os.LookupEnv("URL")
+		return api.SelectServer(cmd.Flags().Changed("url") || urlFromEnv)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -37,6 +54,19 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.vaxctl.yaml)")
+	rootCmd.PersistentFlags().String("url", "", "URL of the server, takes precedence over the contexts (default is the 'url' of the config file)")
+	viper.BindPFlag("url", rootCmd.PersistentFlags().Lookup("url"))
+	rootCmd.PersistentFlags().String("context", "", "name of the context (server) from the config file to use, takes precedence over 'current-context'")
+	viper.BindPFlag("context", rootCmd.PersistentFlags().Lookup("context"))
+	rootCmd.PersistentFlags().Int("verbosity", 0, "log HTTP traffic to stderr with secrets redacted (1: requests, 2: headers, 3: bodies, 4: full bodies), no '-v' shorthand since '-v' is already '--version' and '--verbose' of some commands")
+	viper.BindPFlag("verbosity", rootCmd.PersistentFlags().Lookup("verbosity"))
+	rootCmd.PersistentFlags().String("record", "", "record all API traffic to a HAR file (data is not redacted)")
+	viper.BindPFlag("record", rootCmd.PersistentFlags().Lookup("record"))
+	rootCmd.PersistentFlags().String("replay", "", "serve all API calls from a previously recorded HAR file instead of the server")
+	viper.BindPFlag("replay", rootCmd.PersistentFlags().Lookup("replay"))
+	rootCmd.PersistentFlags().String("dry-run", "none", "'client' prints the requests that would change the server instead of sending them (GET requests are still sent)")
+	rootCmd.PersistentFlags().Lookup("dry-run").NoOptDefVal = "client"
+	viper.BindPFlag("dry-run", rootCmd.PersistentFlags().Lookup("dry-run"))
 }
 
 // initConfig reads in config file and ENV variables if set.
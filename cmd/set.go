@@ -7,7 +7,7 @@ import (
 var setCmd = &cobra.Command{
 	Use:   "set",
 	Short: "Change state of a resource",
-	Long:  `Manually chage the current status of a resource`,
+	Long:  `Manually change the current status or the fields of a resource`,
 	Args:  cobra.NoArgs,
 }
 
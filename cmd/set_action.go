@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var setActionCmd = &cobra.Command{
+	Use:   "action NAME",
+	Short: "Set fields of an action",
+	Long: `Set fields of an action.
+
+Only the fields of the given flags are changed, they are validated against the
+values known by the server before sending.
+
+Examples:
+  # Change the data of an action
+  vaxctl set action power-on --data cycle`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: model.GetActionNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		fields := fieldsFromFlags(cmd)
+		if len(fields) == 0 {
+			fmt.Println("You must set at least one field flag")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.SetResourceFields("action", args[0], fields)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	setCmd.AddCommand(setActionCmd)
+	addActionFieldFlags(setActionCmd)
+}
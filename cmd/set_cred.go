@@ -9,18 +9,44 @@ import (
 )
 
 var setCredCmd = &cobra.Command{
-	Use:   "cred",
-	Short: "Set credential as default",
-	Long: `Set credential to be default.
+	Use:   "cred [NAME]",
+	Short: "Set credential as default or its fields",
+	Long: `Set credential to be default or change its fields.
 
-Set a credential to be the default
+Set a credential to be the default, or change the username and password of a
+credential by name
 
 Examples:
   # Set credential as default 
-  vaxctl set cred -n CRED_NAME`,
-	Args: cobra.NoArgs,
+  vaxctl set cred -n CRED_NAME
+
+  # Change the password of a credential and set it as default
+  vaxctl set cred CRED_NAME --password calvin --default`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: model.GetCredNamesForCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := model.SetCredsAsDefault(name)
+		var err error
+		if len(args) == 1 {
+			fields := fieldsFromFlags(cmd)
+			isDefault, _ := cmd.Flags().GetBool("default")
+			if len(fields) == 0 && !isDefault {
+				fmt.Println("You must set at least one field flag")
+				cmd.Usage()
+				os.Exit(2)
+			}
+			if len(fields) > 0 {
+				err = model.SetResourceFields("creds", args[0], fields)
+			}
+			if err == nil && isDefault {
+				err = model.SetCredsAsDefault(args[0])
+			}
+		} else if name != "" {
+			err = model.SetCredsAsDefault(name)
+		} else {
+			fmt.Println("You must set either a name with flags OR a name to set as default")
+			cmd.Usage()
+			os.Exit(2)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -30,7 +56,7 @@ Examples:
 
 func init() {
 	setCmd.AddCommand(setCredCmd)
-	setCredCmd.Flags().StringVarP(&name, "name", "n", "", "name of the credentials")
+	setCredCmd.Flags().StringVarP(&name, "name", "n", "", "name of the credentials to set as default")
 	setCredCmd.RegisterFlagCompletionFunc("name", model.GetCredNamesForCompletion)
-	setCredCmd.MarkFlagRequired("name")
+	addCredFieldFlags(setCredCmd)
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var setDeviceCmd = &cobra.Command{
+	Use:   "device UID",
+	Short: "Set fields of a device",
+	Long: `Set fields of a device.
+
+Only the fields of the given flags are changed, they are validated against the
+values known by the server before sending.
+
+Examples:
+  # Change the cred of a device and make it a zombie
+  vaxctl set device DEVICE_UID --cred admin --zombie=true
+
+  # Add a metadata key to a device
+  vaxctl set device DEVICE_UID --meta rack=B2`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: model.GetDeviceNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		fields := fieldsFromFlags(cmd)
+		if len(fields) == 0 {
+			fmt.Println("You must set at least one field flag")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.SetResourceFields("device", args[0], fields)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	setCmd.AddCommand(setDeviceCmd)
+	addDeviceFieldFlags(setDeviceCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var setRuleCmd = &cobra.Command{
+	Use:   "rule NAME",
+	Short: "Set fields of a rule",
+	Long: `Set fields of a rule.
+
+Only the fields of the given flags are changed, they are validated against the
+values known by the server before sending.
+
+Examples:
+  # Disable a rule
+  vaxctl set rule RULE_NAME --enabled=false
+
+  # Replace the actions of a rule and move it before another rule
+  vaxctl set rule RULE_NAME --actions press-f2,wait --before boot-menu`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: model.GetRuleNamesForCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		fields := fieldsFromFlags(cmd)
+		if len(fields) == 0 {
+			fmt.Println("You must set at least one field flag")
+			cmd.Usage()
+			os.Exit(2)
+		}
+		err := model.SetResourceFields("rule", args[0], fields)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	setCmd.AddCommand(setRuleCmd)
+	addRuleFieldFlags(setRuleCmd)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulate vaxiin components",
+	Long:  `Simulate vaxiin components for testing rules without touching real devices`,
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var simulateActionsOptions model.SimulateActionsOptions
+
+var simulateActionsCmd = &cobra.Command{
+	Use:   "actions -r RULE",
+	Short: "Print the timeline of the actions of a rule",
+	Long: `Print the timeline of what the console receives when the actions of a rule run.
+
+Every key press and chord of the keystroke actions is a step (typed text is
+broken into keys), sleeps advance the time and power, ipmitool and request
+actions are single steps. Each step is listed with the time it starts at,
+followed by the total expected runtime. Nothing is sent to the device.
+
+Examples:
+  # print the timeline of a rule
+  vaxctl simulate actions -r RULE_NAME
+
+  # resolve the placeholders for a device and assume slower typing
+  vaxctl simulate actions -r RULE_NAME -d DEVICE_UID --key-delay 250ms`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.SimulateActions(simulateActionsOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	simulateCmd.AddCommand(simulateActionsCmd)
+	simulateActionsCmd.Flags().StringVarP(&simulateActionsOptions.RuleName, "rule", "r", "", "name of the rule")
+	simulateActionsCmd.RegisterFlagCompletionFunc("rule", model.GetRuleNamesForCompletion)
+	simulateActionsCmd.MarkFlagRequired("rule")
+	simulateActionsCmd.Flags().StringVarP(&simulateActionsOptions.DeviceUID, "device", "d", "", "uid of device to resolve the placeholders for (if not set they are kept)")
+	simulateActionsCmd.RegisterFlagCompletionFunc("device", model.GetDeviceNamesForCompletion)
+	simulateActionsCmd.Flags().DurationVar(&simulateActionsOptions.KeyDelay, "key-delay", 100*time.Millisecond, "time assumed for each key press")
+	simulateActionsCmd.Flags().DurationVar(&simulateActionsOptions.StepTime, "step-time", time.Second, "time assumed for each power, ipmitool and request action")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var simulateAgentOptions model.SimulateAgentOptions
+
+var simulateAgentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Emulate an agent for a device",
+	Long: `Emulate an agent for a device.
+
+Registers the device (as a zombie) if it does not exist, then on every interval
+sends a heartbeat, posts the next screenshot from the screens directory as a state
+and runs any pending work, reporting each execution according to the script.
+
+A '.txt' file next to a screenshot (same name) is sent as its OCR text.
+
+Script file example (YAML):
+  default: success
+  elapsed_time: 1.5
+  actions:
+    Press F1: failure
+
+Examples:
+  # post 3 states 10 seconds apart, the screenshots are posted in name order
+  # and start over when there are fewer than 3
+  vaxctl simulate agent -d DEVICE_UID -s ./pngs/ -n 3
+
+  # run until stopped, failing actions listed in the script
+  vaxctl simulate agent -d DEVICE_UID -s ./pngs/ -n 0 --script script.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.SimulateAgent(simulateAgentOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	simulateCmd.AddCommand(simulateAgentCmd)
+	simulateAgentCmd.Flags().StringVarP(&simulateAgentOptions.DeviceUID, "device", "d", "", "uid of device to emulate")
+	simulateAgentCmd.RegisterFlagCompletionFunc("device", model.GetDeviceNamesForCompletion)
+	simulateAgentCmd.MarkFlagRequired("device")
+	simulateAgentCmd.Flags().StringVarP(&simulateAgentOptions.ScreensDir, "screens", "s", "", "directory with PNG screenshots to post (in name order)")
+	simulateAgentCmd.MarkFlagRequired("screens")
+	simulateAgentCmd.Flags().StringVar(&simulateAgentOptions.ScriptFile, "script", "", "file with the status to report per action (JSON and YAML formats are accepted)")
+	simulateAgentCmd.Flags().DurationVarP(&simulateAgentOptions.Interval, "interval", "t", 10*time.Second, "time between states")
+	simulateAgentCmd.Flags().IntVarP(&simulateAgentOptions.Iterations, "iterations", "n", 0, "number of states to post, the screenshots are reused in a loop (0 runs until stopped)")
+	simulateAgentCmd.Flags().StringVar(&simulateAgentOptions.IpmiIp, "ipmi-ip", "127.0.0.1", "IPMI IP to register the device with")
+	simulateAgentCmd.Flags().StringVar(&simulateAgentOptions.Model, "model", "simulated", "model to register the device with")
+}
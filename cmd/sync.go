@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var syncOptions model.SyncOptions
+
+var syncCmd = &cobra.Command{
+	Use:   "sync -f DIRECTORY",
+	Short: "Reconcile the server to the resources in files",
+	Long: `Reconcile the server to the resources in files (JSON and YAML formats are accepted).
+
+The files hold '---' separated documents with a 'kind' field (cred, device,
+action or rule). Resources that differ from the server are created/updated and
+rules are ordered like they appear in the files.
+
+With '--prune' resources of the synced kinds that are not in the files anymore
+are deleted, only those that were synced before unless '--prune-all' is set.
+Synced devices are tagged on the server with the 'vaxctl-sync: managed' metadata
+key, which apply, set and patch keep ('label device UID vaxctl-sync-' removes
+it). The other kinds have no labels on the server, they are listed in the
+'.vaxctl-sync.yaml' inventory written next to the files after '--yes', so commit
+that file: without it (e.g. on a fresh CI checkout) only devices are pruned.
+
+The plan is printed first and is only carried out with '--yes'.
+
+Examples:
+  # show what would change
+  vaxctl sync -f ./vaxiin/ --prune
+
+  # reconcile the server and delete resources removed from the directory
+  vaxctl sync -f ./vaxiin/ --prune --yes`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		syncOptions.Source = manifestSource()
+		err := model.Sync(syncOptions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	addManifestFlags(syncCmd, "files, directories or overlays to sync")
+	syncCmd.Flags().BoolVar(&syncOptions.Prune, "prune", false, "delete synced resources that are not in the files anymore")
+	syncCmd.Flags().BoolVar(&syncOptions.PruneAll, "prune-all", false, "delete all resources of the synced kinds that are not in the files")
+	syncCmd.Flags().BoolVar(&syncOptions.Yes, "yes", false, "carry out the plan")
+	syncCmd.Flags().StringVar(&syncOptions.Inventory, "inventory", "", "inventory file of the synced resources (default is '.vaxctl-sync.yaml' in the directory)")
+	syncCmd.MarkFlagRequired("filename")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate -f FILENAME",
+	Short: "Validate resource files offline",
+	Long: `Validate resource files against the schemas of their kinds without contacting the server.
+
+Every document is checked for its mandatory fields, the types of the fields,
+the allowed values (e.g. 'action_type') and the fields that can't be set
+together ('before_rule'/'after_rule', 'state_id'/'screenshot'). References to
+other resources are not checked.
+
+Examples:
+  # validate all documents in a file
+  vaxctl validate -f fleet.yaml
+
+  # validate all YAML/JSON files in a directory and its sub-directories
+  vaxctl validate -f manifests/ -R`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.ValidateManifests(manifestSource())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	addManifestFlags(validateCmd, "files, directories, overlays or '-' (stdin) to validate")
+	validateCmd.MarkFlagRequired("filename")
+}
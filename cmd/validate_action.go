@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"vaxctl/model"
+
+	"github.com/spf13/cobra"
+)
+
+var actionData string
+
+var validateActionCmd = &cobra.Command{
+	Use:   "action",
+	Short: "Validate the data of keystroke actions",
+	Long: `Validate the data of keystroke actions against the special keys of the server.
+
+The data is a ';' separated list of combos, each one either a text typed key by
+key or keys pressed at once separated by '+' (special keys are prefixed with
+'Keys.'). Empty combos, unknown special keys and keys that are not a single
+character are reported with their position, and the canonical form is printed
+when it differs.
+
+Examples:
+  # validate all keystroke actions
+  vaxctl validate action
+
+  # validate an action by name
+  vaxctl validate action -n ACTION_NAME
+
+  # validate action data before creating the action
+  vaxctl validate action --data 'Keys.Control+c;exit;Keys.Enter'`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := model.ValidateKeystrokeActions(name, actionData)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	validateCmd.AddCommand(validateActionCmd)
+	validateActionCmd.Flags().StringVarP(&name, "name", "n", "", "name of the action (if not set all keystroke actions are validated)")
+	validateActionCmd.RegisterFlagCompletionFunc("name", model.GetActionNamesForCompletion)
+	validateActionCmd.Flags().StringVarP(&actionData, "data", "d", "", "keystroke action data to validate instead of the server actions")
+}
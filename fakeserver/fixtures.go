@@ -0,0 +1,84 @@
+package fakeserver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"vaxctl/helpers"
+	"vaxctl/model"
+
+	"github.com/ghodss/yaml"
+)
+
+// Fixtures are the resources the server starts with. States may set
+// 'ocr_text' directly since the fake server has no OCR engine.
+type Fixtures struct {
+	Creds   []model.Cred   `json:"creds,omitempty"`
+	Devices []model.Device `json:"devices,omitempty"`
+	Actions []model.Action `json:"actions,omitempty"`
+	Rules   []FixtureRule  `json:"rules,omitempty"`
+	States  []model.State  `json:"states,omitempty"`
+}
+
+// FixtureRule mirrors the rule payload, unset booleans keep the server
+// defaults and 'state_id' can reference a seeded state (IDs start at 1).
+type FixtureRule struct {
+	Name       string   `json:"name"`
+	Regex      string   `json:"regex"`
+	Actions    []string `json:"actions"`
+	IgnoreCase *bool    `json:"ignore_case,omitempty"`
+	Enabled    *bool    `json:"enabled,omitempty"`
+	AfterRule  string   `json:"after_rule,omitempty"`
+	BeforeRule string   `json:"before_rule,omitempty"`
+	Screenshot string   `json:"screenshot,omitempty"`
+	OcrText    string   `json:"ocr_text,omitempty"`
+	StateId    int      `json:"state_id,omitempty"`
+}
+
+type seed struct {
+	description string
+	data        interface{}
+	create      routeFunc
+}
+
+func LoadFixtures(filename string) (Fixtures, error) {
+	var fixtures Fixtures
+	if filename == "" {
+		return fixtures, nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fixtures, err
+	}
+	jsonData, err := helpers.ToJSON(data)
+	if err != nil {
+		return fixtures, err
+	}
+	err = yaml.Unmarshal(jsonData, &fixtures)
+	return fixtures, err
+}
+
+func (f Fixtures) seeds() []seed {
+	var seeds []seed
+	for _, cred := range f.Creds {
+		seeds = append(seeds, seed{fmt.Sprintf("cred '%s'", cred.Name), cred, createCred})
+	}
+	for _, action := range f.Actions {
+		seeds = append(seeds, seed{fmt.Sprintf("action '%s'", action.Name), action, createAction})
+	}
+	for _, device := range f.Devices {
+		seeds = append(seeds, seed{fmt.Sprintf("device '%s'", device.UID), device, createDevice})
+	}
+	for _, state := range f.States {
+		seeds = append(seeds, seed{fmt.Sprintf("state for device '%s'", state.DeviceUID), state, seedState})
+	}
+	for _, rule := range f.Rules {
+		seeds = append(seeds, seed{fmt.Sprintf("rule '%s'", rule.Name), rule, createRule})
+	}
+	return seeds
+}
+
+func seedState(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	delete(body, "state_id")
+	return createOrUpdateState(h, r, body)
+}
@@ -0,0 +1,504 @@
+package fakeserver
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"vaxctl/model"
+)
+
+type store struct {
+	creds           []model.Cred
+	devices         []model.Device
+	actions         []model.Action
+	rules           []model.Rule
+	states          []model.State
+	works           []model.Work
+	executions      []model.Execution
+	nextStateId     int
+	nextWorkId      int
+	nextExecutionId int
+}
+
+func newStore() *store {
+	return &store{nextStateId: 1, nextWorkId: 1, nextExecutionId: 1}
+}
+
+func listActionTypes(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.ActionTypeResponse{ActionTypes: model.KnownActionTypes}, nil
+}
+
+func listPowerOptions(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.PowerOptionsResponse{PowerOptions: PowerOptions}, nil
+}
+
+func listSpecialKeys(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.SpecialKeysResponse{SpecialKeys: SpecialKeys}, nil
+}
+
+// creds
+
+func (s *store) findCred(name string) int {
+	for idx, cred := range s.creds {
+		if cred.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *store) defaultCredName() string {
+	for _, cred := range s.creds {
+		if cred.IsDefault {
+			return cred.Name
+		}
+	}
+	return ""
+}
+
+func getAllCreds(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.CredsResponse{Creds: append([]model.Cred{}, h.store.creds...)}, nil
+}
+
+func getCred(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findCred(name)
+	if idx == -1 {
+		return nil, notFound("creds", name)
+	}
+	return model.CredsResponse{Creds: []model.Cred{h.store.creds[idx]}}, nil
+}
+
+func createCred(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "name", "username", "password")
+	if err != nil {
+		return nil, err
+	}
+	var cred model.Cred
+	err = fromMap(body, nil, &cred)
+	if err != nil {
+		return nil, err
+	}
+	if h.store.findCred(cred.Name) != -1 {
+		return nil, conflict("creds", cred.Name)
+	}
+	cred.IsDefault = len(h.store.creds) == 0
+	cred.CreatedAt = timestamp()
+	cred.LastUpdated = cred.CreatedAt
+	h.store.creds = append(h.store.creds, cred)
+	return model.CredsResponse{Creds: []model.Cred{cred}}, nil
+}
+
+func updateCred(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := stringField(body, "name")
+	idx := h.store.findCred(name)
+	if idx == -1 {
+		return nil, notFound("creds", name)
+	}
+	cred := h.store.creds[idx]
+	delete(body, "is_default")
+	err := fromMap(body, cred, &cred)
+	if err != nil {
+		return nil, err
+	}
+	cred.LastUpdated = timestamp()
+	h.store.creds[idx] = cred
+	return model.CredsResponse{Creds: []model.Cred{cred}}, nil
+}
+
+func deleteCred(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findCred(name)
+	if idx == -1 {
+		return nil, notFound("creds", name)
+	}
+	if h.store.creds[idx].IsDefault {
+		return nil, badRequest("creds '%s' is the default and can't be deleted", name)
+	}
+	for _, device := range h.store.devices {
+		if device.CredsName == name {
+			return nil, badRequest("creds '%s' is used by device '%s'", name, device.UID)
+		}
+	}
+	h.store.creds = append(h.store.creds[:idx], h.store.creds[idx+1:]...)
+	return map[string]string{"message": "deleted"}, nil
+}
+
+func setDefaultCred(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findCred(name)
+	if idx == -1 {
+		return nil, notFound("creds", name)
+	}
+	for credIdx := range h.store.creds {
+		h.store.creds[credIdx].IsDefault = credIdx == idx
+	}
+	return model.CredsResponse{Creds: []model.Cred{h.store.creds[idx]}}, nil
+}
+
+// devices
+
+func (s *store) findDevice(uid string) int {
+	for idx, device := range s.devices {
+		if device.UID == uid {
+			return idx
+		}
+	}
+	return -1
+}
+
+func getAllDevices(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.DevicesResponse{Devices: append([]model.Device{}, h.store.devices...)}, nil
+}
+
+func getDevice(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	uid := r.URL.Query().Get("uid")
+	idx := h.store.findDevice(uid)
+	if idx == -1 {
+		return nil, notFound("device", uid)
+	}
+	return model.DevicesResponse{Devices: []model.Device{h.store.devices[idx]}}, nil
+}
+
+func (s *store) validateDevice(device *model.Device) error {
+	if device.CredsName == "" || device.CredsName == "default" {
+		device.CredsName = s.defaultCredName()
+	}
+	if s.findCred(device.CredsName) == -1 {
+		return badRequest("creds '%s' was not found", device.CredsName)
+	}
+	if device.Metadata == nil {
+		device.Metadata = map[string]string{}
+	}
+	return nil
+}
+
+func createDevice(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "uid", "ipmi_ip", "model")
+	if err != nil {
+		return nil, err
+	}
+	var device model.Device
+	err = fromMap(body, nil, &device)
+	if err != nil {
+		return nil, err
+	}
+	if h.store.findDevice(device.UID) != -1 {
+		return nil, conflict("device", device.UID)
+	}
+	err = h.store.validateDevice(&device)
+	if err != nil {
+		return nil, err
+	}
+	device.CreatedAt = timestamp()
+	device.LastUpdated = device.CreatedAt
+	h.store.devices = append(h.store.devices, device)
+	return model.DevicesResponse{Devices: []model.Device{device}}, nil
+}
+
+func updateDevice(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	uid := stringField(body, "uid")
+	idx := h.store.findDevice(uid)
+	if idx == -1 {
+		return nil, notFound("device", uid)
+	}
+	device := h.store.devices[idx]
+	err := fromMap(body, device, &device)
+	if err != nil {
+		return nil, err
+	}
+	err = h.store.validateDevice(&device)
+	if err != nil {
+		return nil, err
+	}
+	device.LastUpdated = timestamp()
+	h.store.devices[idx] = device
+	return model.DevicesResponse{Devices: []model.Device{device}}, nil
+}
+
+func deleteDevice(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	uid := r.URL.Query().Get("uid")
+	idx := h.store.findDevice(uid)
+	if idx == -1 {
+		return nil, notFound("device", uid)
+	}
+	h.store.devices = append(h.store.devices[:idx], h.store.devices[idx+1:]...)
+	return map[string]string{"message": "deleted"}, nil
+}
+
+func heartbeat(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "uid")
+	if err != nil {
+		return nil, err
+	}
+	uid := stringField(body, "uid")
+	idx := h.store.findDevice(uid)
+	if idx == -1 {
+		return nil, notFound("device", uid)
+	}
+	h.store.devices[idx].AgentVersion = stringField(body, "agent_version")
+	h.store.devices[idx].HeartbeatTimestamp = timestamp()
+	return model.DevicesResponse{Devices: []model.Device{h.store.devices[idx]}}, nil
+}
+
+// actions
+
+func (s *store) findAction(name string) int {
+	for idx, action := range s.actions {
+		if action.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func getAllActions(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.ActionsResponse{Actions: append([]model.Action{}, h.store.actions...)}, nil
+}
+
+func getAction(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findAction(name)
+	if idx == -1 {
+		return nil, notFound("action", name)
+	}
+	return model.ActionsResponse{Actions: []model.Action{h.store.actions[idx]}}, nil
+}
+
+func validateAction(action model.Action) error {
+	for _, actionType := range model.KnownActionTypes {
+		if action.Type == actionType {
+			return nil
+		}
+	}
+	return badRequest("action type '%s' is not supported", action.Type)
+}
+
+func createAction(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "name", "action_type", "action_data")
+	if err != nil {
+		return nil, err
+	}
+	var action model.Action
+	err = fromMap(body, nil, &action)
+	if err != nil {
+		return nil, err
+	}
+	if h.store.findAction(action.Name) != -1 {
+		return nil, conflict("action", action.Name)
+	}
+	err = validateAction(action)
+	if err != nil {
+		return nil, err
+	}
+	action.CreatedAt = timestamp()
+	action.LastUpdated = action.CreatedAt
+	h.store.actions = append(h.store.actions, action)
+	return model.ActionsResponse{Actions: []model.Action{action}}, nil
+}
+
+func updateAction(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := stringField(body, "name")
+	idx := h.store.findAction(name)
+	if idx == -1 {
+		return nil, notFound("action", name)
+	}
+	action := h.store.actions[idx]
+	err := fromMap(body, action, &action)
+	if err != nil {
+		return nil, err
+	}
+	err = validateAction(action)
+	if err != nil {
+		return nil, err
+	}
+	action.LastUpdated = timestamp()
+	h.store.actions[idx] = action
+	return model.ActionsResponse{Actions: []model.Action{action}}, nil
+}
+
+func deleteAction(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findAction(name)
+	if idx == -1 {
+		return nil, notFound("action", name)
+	}
+	for _, rule := range h.store.rules {
+		for _, actionName := range rule.Actions {
+			if actionName == name {
+				return nil, badRequest("action '%s' is used by rule '%s'", name, rule.Name)
+			}
+		}
+	}
+	h.store.actions = append(h.store.actions[:idx], h.store.actions[idx+1:]...)
+	return map[string]string{"message": "deleted"}, nil
+}
+
+// rules
+
+func (s *store) findRule(name string) int {
+	for idx, rule := range s.rules {
+		if rule.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *store) orderedRules() []model.Rule {
+	rules := make([]model.Rule, len(s.rules))
+	for idx, rule := range s.rules {
+		rule.Position = idx + 1
+		rules[idx] = rule
+	}
+	return rules
+}
+
+func getAllRules(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.RulesResponse{Rules: h.store.orderedRules()}, nil
+}
+
+func getRule(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findRule(name)
+	if idx == -1 {
+		return nil, notFound("rule", name)
+	}
+	return model.RulesResponse{Rules: []model.Rule{h.store.orderedRules()[idx]}}, nil
+}
+
+func (s *store) validateRule(rule model.Rule) error {
+	if rule.AfterRule != "" && rule.BeforeRule != "" {
+		return badRequest("only one of [before_rule, after_rule] can be set")
+	}
+	for _, anchor := range []string{rule.AfterRule, rule.BeforeRule} {
+		if anchor != "" && (anchor == rule.Name || s.findRule(anchor) == -1) {
+			return badRequest("rule '%s' can't be used for ordering", anchor)
+		}
+	}
+	_, err := compileRuleRegex(rule)
+	if err != nil {
+		return badRequest("regex is not valid: %v", err)
+	}
+	for _, actionName := range rule.Actions {
+		if s.findAction(actionName) == -1 {
+			return badRequest("action '%s' was not found", actionName)
+		}
+	}
+	return nil
+}
+
+// placeRule inserts the rule at the position requested by after_rule or
+// before_rule, new rules without them are added last.
+func (s *store) placeRule(rule model.Rule, currentIdx int) {
+	if currentIdx != -1 {
+		s.rules = append(s.rules[:currentIdx], s.rules[currentIdx+1:]...)
+	}
+	newIdx := len(s.rules)
+	if currentIdx != -1 && rule.AfterRule == "" && rule.BeforeRule == "" {
+		newIdx = currentIdx
+	}
+	if rule.AfterRule != "" {
+		newIdx = s.findRule(rule.AfterRule) + 1
+	} else if rule.BeforeRule != "" {
+		newIdx = s.findRule(rule.BeforeRule)
+	}
+	rule.AfterRule = ""
+	rule.BeforeRule = ""
+	rule.Position = 0
+	s.rules = append(s.rules, model.Rule{})
+	copy(s.rules[newIdx+1:], s.rules[newIdx:])
+	s.rules[newIdx] = rule
+}
+
+func createRule(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "name", "regex", "actions")
+	if err != nil {
+		return nil, err
+	}
+	var rule model.Rule
+	err = fromMap(body, nil, &rule)
+	if err != nil {
+		return nil, err
+	}
+	if h.store.findRule(rule.Name) != -1 {
+		return nil, conflict("rule", rule.Name)
+	}
+	if rule.Screenshot == "" {
+		stateId, ok := body["state_id"]
+		if !ok {
+			return nil, badRequest("one of [state_id, screenshot] must be set")
+		}
+		state, err := h.store.stateById(stateId)
+		if err != nil {
+			return nil, err
+		}
+		rule.Screenshot = state.Screenshot
+		rule.OcrText = state.OcrText
+	}
+	if _, ok := body["ignore_case"]; !ok {
+		rule.IgnoreCase = true
+	}
+	if _, ok := body["enabled"]; !ok {
+		rule.Enabled = true
+	}
+	err = h.store.validateRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	rule.CreatedAt = timestamp()
+	rule.LastUpdated = rule.CreatedAt
+	h.store.placeRule(rule, -1)
+	return getRule(h, ruleRequest(rule.Name), nil)
+}
+
+func updateRule(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := stringField(body, "name")
+	idx := h.store.findRule(name)
+	if idx == -1 {
+		return nil, notFound("rule", name)
+	}
+	rule := h.store.rules[idx]
+	delete(body, "position")
+	err := fromMap(body, rule, &rule)
+	if err != nil {
+		return nil, err
+	}
+	if stateId, ok := body["state_id"]; ok && stringField(body, "screenshot") == "" {
+		state, err := h.store.stateById(stateId)
+		if err != nil {
+			return nil, err
+		}
+		rule.Screenshot = state.Screenshot
+		rule.OcrText = state.OcrText
+	}
+	err = h.store.validateRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	rule.LastUpdated = timestamp()
+	h.store.placeRule(rule, idx)
+	return getRule(h, ruleRequest(rule.Name), nil)
+}
+
+func deleteRule(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findRule(name)
+	if idx == -1 {
+		return nil, notFound("rule", name)
+	}
+	h.store.rules = append(h.store.rules[:idx], h.store.rules[idx+1:]...)
+	return map[string]string{"message": "deleted"}, nil
+}
+
+func ruleRequest(name string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/?"+url.Values{"name": {name}}.Encode(), nil)
+	return request
+}
+
+func compileRuleRegex(rule model.Rule) (*regexp.Regexp, error) {
+	if rule.IgnoreCase {
+		return regexp.Compile("(?i)" + rule.Regex)
+	}
+	return regexp.Compile(rule.Regex)
+}
@@ -0,0 +1,273 @@
+// Package fakeserver implements an in-memory vaxiin server that serves the
+// '/api/v1' routes used by vaxctl, for local development and tests.
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const apiPrefix = "/api/v1/"
+
+var (
+	PowerOptions = []string{"on", "off", "cycle", "reset", "soft", "status"}
+	SpecialKeys  = []string{
+		"Alt", "Backspace", "CapsLock", "Control", "Delete", "Down", "End", "Enter", "Escape",
+		"F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9", "F10", "F11", "F12",
+		"Home", "Insert", "Left", "PageDown", "PageUp", "Right", "Shift", "Space", "Tab", "Up",
+	}
+)
+
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+type Handler struct {
+	lock  sync.Mutex
+	store *store
+}
+
+type httpError struct {
+	status  int
+	message string
+	errors  map[string]string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+type routeFunc func(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error)
+
+type route struct {
+	method string
+	path   string
+}
+
+var routes = map[route]routeFunc{
+	{"GET", "action/list-types"}:         listActionTypes,
+	{"GET", "action/list-power-options"}: listPowerOptions,
+	{"GET", "action/list-special-keys"}:  listSpecialKeys,
+
+	{"GET", "creds/all"}:     getAllCreds,
+	{"GET", "creds/"}:        getCred,
+	{"POST", "creds/"}:       createCred,
+	{"PUT", "creds/"}:        updateCred,
+	{"DELETE", "creds/"}:     deleteCred,
+	{"PUT", "creds/default"}: setDefaultCred,
+
+	{"GET", "device/all"}:        getAllDevices,
+	{"GET", "device/"}:           getDevice,
+	{"POST", "device/"}:          createDevice,
+	{"PUT", "device/"}:           updateDevice,
+	{"DELETE", "device/"}:        deleteDevice,
+	{"POST", "device/heartbeat"}: heartbeat,
+
+	{"GET", "action/all"}: getAllActions,
+	{"GET", "action/"}:    getAction,
+	{"POST", "action/"}:   createAction,
+	{"PUT", "action/"}:    updateAction,
+	{"DELETE", "action/"}: deleteAction,
+
+	{"GET", "rule/all"}:     getAllRules,
+	{"GET", "rule/ordered"}: getAllRules,
+	{"GET", "rule/"}:        getRule,
+	{"POST", "rule/"}:       createRule,
+	{"PUT", "rule/"}:        updateRule,
+	{"DELETE", "rule/"}:     deleteRule,
+
+	{"GET", "state/all"}:             getAllStates,
+	{"GET", "state/"}:                getState,
+	{"PUT", "state/"}:                createOrUpdateState,
+	{"POST", "state/resolve"}:        resolveState,
+	{"POST", "state/update-resolve"}: updateResolvedState,
+
+	{"GET", "work/all"}:           getAllWorks,
+	{"GET", "work/all/by-device"}: getWorksByDevice,
+	{"GET", "work/by-id"}:         getWorkByID,
+	{"POST", "work/"}:             assignWork,
+	{"POST", "work/by-id"}:        completeWork,
+
+	{"GET", "execution/all/by-work-id"}: getExecutionsByWork,
+	{"POST", "execution/"}:              createExecution,
+}
+
+var screenshotRoutes = map[string]func(h *Handler, r *http.Request) ([]byte, error){
+	"screenshot/by-id":     getScreenshotByStateId,
+	"screenshot/by-device": getScreenshotByDevice,
+	"screenshot/by-rule":   getScreenshotByRule,
+}
+
+// New starts a fake server seeded with the given fixtures, the caller
+// should Close it when done.
+func New(fixtures Fixtures) (*Server, error) {
+	handler, err := NewHandler(fixtures)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Server: httptest.NewServer(handler), Handler: handler}, nil
+}
+
+func NewHandler(fixtures Fixtures) (*Handler, error) {
+	handler := &Handler{store: newStore()}
+	err := handler.Seed(fixtures)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Seed loads the fixtures through the same code path as API requests so
+// they are validated and ordered like any other resource.
+func (h *Handler) Seed(fixtures Fixtures) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, seed := range fixtures.seeds() {
+		body, err := toMap(seed.data)
+		if err != nil {
+			return err
+		}
+		_, err = seed.create(h, &http.Request{}, body)
+		if err != nil {
+			return fmt.Errorf("failed to seed %s: %v", seed.description, err)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		writeError(w, &httpError{status: http.StatusNotFound, message: "not found"})
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if screenshotFunc, ok := screenshotRoutes[path]; ok && r.Method == http.MethodGet {
+		data, err := screenshotFunc(h, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+		return
+	}
+
+	routeFunc, ok := routes[route{r.Method, path}]
+	if !ok {
+		writeError(w, &httpError{status: http.StatusNotFound, message: fmt.Sprintf("%s %s is not implemented", r.Method, path)})
+		return
+	}
+
+	body := map[string]interface{}{}
+	if r.Body != nil && r.ContentLength != 0 {
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		err := decoder.Decode(&body)
+		if err != nil && err != io.EOF {
+			writeError(w, &httpError{status: http.StatusBadRequest, message: "failed to decode JSON payload"})
+			return
+		}
+	}
+
+	response, err := routeFunc(h, r, body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	httpErr, ok := err.(*httpError)
+	if !ok {
+		httpErr = &httpError{status: http.StatusInternalServerError, message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": httpErr.message, "errors": httpErr.errors})
+}
+
+func notFound(kind string, key string) error {
+	return &httpError{status: http.StatusNotFound, message: fmt.Sprintf("%s '%s' was not found", kind, key)}
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func conflict(kind string, key string) error {
+	return &httpError{status: http.StatusConflict, message: fmt.Sprintf("%s '%s' already exists", kind, key)}
+}
+
+func requireFields(body map[string]interface{}, fields ...string) error {
+	errors := map[string]string{}
+	for _, field := range fields {
+		if value, ok := body[field]; !ok || value == nil || value == "" {
+			errors[field] = fmt.Sprintf("'%s' is a required property", field)
+		}
+	}
+	if len(errors) > 0 {
+		return &httpError{status: http.StatusBadRequest, message: "Input payload validation failed", errors: errors}
+	}
+	return nil
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05")
+}
+
+func toMap(data interface{}) (map[string]interface{}, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	err = json.Unmarshal(jsonData, &result)
+	return result, err
+}
+
+// fromMap decodes a request body into a resource, when 'base' is set the
+// body is merged over it so only the given fields are updated.
+func fromMap(body map[string]interface{}, base interface{}, target interface{}) error {
+	merged := map[string]interface{}{}
+	if base != nil {
+		var err error
+		merged, err = toMap(base)
+		if err != nil {
+			return err
+		}
+	}
+	for key, value := range body {
+		merged[key] = value
+	}
+	jsonData, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	// the maps of the base are replaced, not merged by the decoder
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	err = json.Unmarshal(jsonData, target)
+	if err != nil {
+		return badRequest("Input payload validation failed: %v", err)
+	}
+	return nil
+}
+
+func stringField(body map[string]interface{}, field string) string {
+	value, _ := body[field].(string)
+	return value
+}
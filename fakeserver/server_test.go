@@ -0,0 +1,252 @@
+package fakeserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"vaxctl/fakeserver"
+	"vaxctl/model"
+)
+
+// off sets the booleans of the fixture rules that default to true.
+var off = false
+
+// request sends the body as JSON and decodes the response when the status is
+// 200, the status is returned.
+func request(t *testing.T, server *fakeserver.Server, method string, path string, body interface{}, response interface{}) int {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	httpRequest, err := http.NewRequest(method, server.URL+"/api/v1/"+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpResponse, err := server.Client().Do(httpRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode == http.StatusOK && response != nil {
+		err = json.NewDecoder(httpResponse.Body).Decode(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return httpResponse.StatusCode
+}
+
+func newServer(t *testing.T, fixtures fakeserver.Fixtures) *fakeserver.Server {
+	t.Helper()
+	server, err := fakeserver.New(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRulePlacement(t *testing.T) {
+	fixtures := fakeserver.Fixtures{
+		Actions: []model.Action{{Name: "press-f1", Type: "keystroke", Data: "Keys.F1"}},
+		Rules: []fakeserver.FixtureRule{
+			{Name: "a", Regex: "a", Actions: []string{"press-f1"}, Screenshot: "iVBORw0KGgo="},
+			{Name: "b", Regex: "b", Actions: []string{"press-f1"}, Screenshot: "iVBORw0KGgo="},
+			{Name: "c", Regex: "c", Actions: []string{"press-f1"}, Screenshot: "iVBORw0KGgo="},
+		},
+	}
+	newRule := func(name string, fields map[string]interface{}) map[string]interface{} {
+		rule := map[string]interface{}{"name": name, "regex": name, "actions": []string{"press-f1"}, "screenshot": "iVBORw0KGgo="}
+		for key, value := range fields {
+			rule[key] = value
+		}
+		return rule
+	}
+	tests := []struct {
+		name       string
+		method     string
+		body       map[string]interface{}
+		wantStatus int
+		wantOrder  []string
+	}{
+		{"new rule is added last", "POST", newRule("d", nil), http.StatusOK, []string{"a", "b", "c", "d"}},
+		{"new rule after a rule", "POST", newRule("d", map[string]interface{}{"after_rule": "a"}), http.StatusOK, []string{"a", "d", "b", "c"}},
+		{"new rule after the last rule", "POST", newRule("d", map[string]interface{}{"after_rule": "c"}), http.StatusOK, []string{"a", "b", "c", "d"}},
+		{"new rule before the first rule", "POST", newRule("d", map[string]interface{}{"before_rule": "a"}), http.StatusOK, []string{"d", "a", "b", "c"}},
+		{"update keeps the position", "PUT", map[string]interface{}{"name": "b", "regex": "bb"}, http.StatusOK, []string{"a", "b", "c"}},
+		{"update moves after a rule", "PUT", map[string]interface{}{"name": "a", "after_rule": "c"}, http.StatusOK, []string{"b", "c", "a"}},
+		{"update moves before a rule", "PUT", map[string]interface{}{"name": "c", "before_rule": "b"}, http.StatusOK, []string{"a", "c", "b"}},
+		{"position is ignored", "PUT", map[string]interface{}{"name": "c", "position": 1}, http.StatusOK, []string{"a", "b", "c"}},
+		{"unknown anchor", "POST", newRule("d", map[string]interface{}{"after_rule": "x"}), http.StatusBadRequest, []string{"a", "b", "c"}},
+		{"rule as its own anchor", "PUT", map[string]interface{}{"name": "b", "before_rule": "b"}, http.StatusBadRequest, []string{"a", "b", "c"}},
+		{"both anchors", "POST", newRule("d", map[string]interface{}{"after_rule": "a", "before_rule": "c"}), http.StatusBadRequest, []string{"a", "b", "c"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newServer(t, fixtures)
+			status := request(t, server, test.method, "rule/", test.body, nil)
+			if status != test.wantStatus {
+				t.Fatalf("got status %d, want %d", status, test.wantStatus)
+			}
+			var response model.RulesResponse
+			request(t, server, "GET", "rule/ordered", nil, &response)
+			var order []string
+			for idx, rule := range response.Rules {
+				if rule.Position != idx+1 {
+					t.Errorf("rule '%s' has position %d, want %d", rule.Name, rule.Position, idx+1)
+				}
+				if rule.AfterRule != "" || rule.BeforeRule != "" {
+					t.Errorf("rule '%s' kept its anchors", rule.Name)
+				}
+				order = append(order, rule.Name)
+			}
+			if !reflect.DeepEqual(order, test.wantOrder) {
+				t.Fatalf("got order %v, want %v", order, test.wantOrder)
+			}
+		})
+	}
+}
+
+func TestStateMatching(t *testing.T) {
+	fixtures := fakeserver.Fixtures{
+		Creds: []model.Cred{{Name: "admin", Username: "root", Password: "calvin123"}},
+		Devices: []model.Device{
+			{UID: "zombie", IpmiIp: "10.0.0.1", CredsName: "admin", Model: "r640", Zombie: true},
+			{UID: "live", IpmiIp: "10.0.0.2", CredsName: "admin", Model: "r640"},
+		},
+		Actions: []model.Action{
+			{Name: "press-f1", Type: "keystroke", Data: "Keys.F1"},
+			{Name: "reboot", Type: "power", Data: "cycle"},
+		},
+		Rules: []fakeserver.FixtureRule{
+			{Name: "disabled", Regex: "press", Actions: []string{"reboot"}, Enabled: &off, Screenshot: "iVBORw0KGgo="},
+			{Name: "f1-prompt", Regex: "press f1", Actions: []string{"press-f1"}, Screenshot: "iVBORw0KGgo="},
+			{Name: "boot-error", Regex: "BOOT ERROR", Actions: []string{"reboot"}, IgnoreCase: &off, Screenshot: "iVBORw0KGgo="},
+			{Name: "any-press", Regex: "press", Actions: []string{"reboot"}, Screenshot: "iVBORw0KGgo="},
+		},
+	}
+	tests := []struct {
+		name        string
+		device      string
+		ocrText     string
+		resolved    bool
+		wantMatch   string
+		wantActions []string
+	}{
+		{"first enabled match wins", "zombie", "Press F1 to continue", false, "f1-prompt", []string{"press-f1"}},
+		{"later rules match too", "zombie", "press any key", false, "any-press", []string{"reboot"}},
+		{"case sensitive rule", "zombie", "boot error", false, "", nil},
+		{"case sensitive match", "zombie", "BOOT ERROR 42", false, "boot-error", []string{"reboot"}},
+		{"no work for live devices", "live", "Press F1 to continue", false, "f1-prompt", nil},
+		{"resolved states are not matched", "zombie", "Press F1 to continue", true, "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newServer(t, fixtures)
+			state := map[string]interface{}{"device_uid": test.device, "screenshot": "aGVsbG8=", "ocr_text": test.ocrText, "resolved": test.resolved}
+			var response model.StatesResponse
+			status := request(t, server, "PUT", "state/", state, &response)
+			if status != http.StatusOK || len(response.States) != 1 {
+				t.Fatalf("got status %d and %d states", status, len(response.States))
+			}
+			if response.States[0].MatchedRule != test.wantMatch {
+				t.Fatalf("got matched rule %q, want %q", response.States[0].MatchedRule, test.wantMatch)
+			}
+			var works model.WorksResponse
+			request(t, server, "GET", "work/all", nil, &works)
+			var actions []string
+			for _, work := range works.Works {
+				if work.Trigger != "rule" || work.StateId != response.States[0].StateId || work.Status != "PENDING" {
+					t.Errorf("unexpected work %+v", work)
+				}
+				for _, action := range work.Actions {
+					actions = append(actions, action.Name)
+				}
+			}
+			if !reflect.DeepEqual(actions, test.wantActions) {
+				t.Fatalf("got work actions %v, want %v", actions, test.wantActions)
+			}
+
+			// the same screenshot refreshes the open state without new work
+			var again model.StatesResponse
+			request(t, server, "PUT", "state/", state, &again)
+			if !test.resolved && again.States[0].StateId != response.States[0].StateId {
+				t.Fatalf("got new state %d for the same screenshot", again.States[0].StateId)
+			}
+			request(t, server, "GET", "work/all", nil, &works)
+			wantWorks := 0
+			if test.wantActions != nil {
+				wantWorks = 1
+			}
+			if len(works.Works) != wantWorks {
+				t.Fatalf("got %d works after the refresh, want %d", len(works.Works), wantWorks)
+			}
+		})
+	}
+}
+
+func TestAssignWork(t *testing.T) {
+	fixtures := fakeserver.Fixtures{
+		Creds:   []model.Cred{{Name: "admin", Username: "root", Password: "calvin123"}},
+		Devices: []model.Device{{UID: "dev1", IpmiIp: "10.0.0.1", CredsName: "admin", Model: "r640"}},
+		Actions: []model.Action{
+			{Name: "press-f1", Type: "keystroke", Data: "Keys.F1"},
+			{Name: "reboot", Type: "power", Data: "cycle"},
+		},
+		Rules: []fakeserver.FixtureRule{
+			{Name: "f1-prompt", Regex: "press f1", Actions: []string{"press-f1", "reboot"}, Screenshot: "iVBORw0KGgo="},
+		},
+		States: []model.State{{DeviceUID: "dev1", Screenshot: "aGVsbG8=", OcrText: "Press F1"}},
+	}
+	tests := []struct {
+		name        string
+		assignment  model.WorkAssignment
+		wantStatus  int
+		wantActions []string
+	}{
+		{"actions of a rule", model.WorkAssignment{DeviceUID: "dev1", Rule: "f1-prompt"}, http.StatusOK, []string{"press-f1", "reboot"}},
+		{"list of actions", model.WorkAssignment{DeviceUID: "dev1", Actions: []string{"reboot", "press-f1"}}, http.StatusOK, []string{"reboot", "press-f1"}},
+		{"rule takes precedence", model.WorkAssignment{DeviceUID: "dev1", Rule: "f1-prompt", Actions: []string{"reboot"}}, http.StatusOK, []string{"press-f1", "reboot"}},
+		{"unknown device", model.WorkAssignment{DeviceUID: "dev2", Actions: []string{"reboot"}}, http.StatusNotFound, nil},
+		{"unknown rule", model.WorkAssignment{DeviceUID: "dev1", Rule: "missing"}, http.StatusNotFound, nil},
+		{"unknown action", model.WorkAssignment{DeviceUID: "dev1", Actions: []string{"reboot", "missing"}}, http.StatusNotFound, nil},
+		{"no actions", model.WorkAssignment{DeviceUID: "dev1"}, http.StatusBadRequest, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newServer(t, fixtures)
+			var response model.WorksResponse
+			status := request(t, server, "POST", "work/", test.assignment, &response)
+			if status != test.wantStatus {
+				t.Fatalf("got status %d, want %d", status, test.wantStatus)
+			}
+			var works model.WorksResponse
+			request(t, server, "GET", "work/all/by-device?uid=dev1", nil, &works)
+			if test.wantStatus != http.StatusOK {
+				if len(works.Works) != 0 {
+					t.Fatalf("got %d works after a failed assignment", len(works.Works))
+				}
+				return
+			}
+			if len(works.Works) != 1 || !reflect.DeepEqual(works.Works[0], response.Works[0]) {
+				t.Fatalf("got works %+v, want the assigned one %+v", works.Works, response.Works)
+			}
+			work := works.Works[0]
+			// the work is tied to the latest state of the device
+			if work.Trigger != "manual" || work.Status != "PENDING" || work.StateId != 1 {
+				t.Errorf("unexpected work %+v", work)
+			}
+			var actions []string
+			for _, action := range work.Actions {
+				actions = append(actions, action.Name)
+			}
+			if !reflect.DeepEqual(actions, test.wantActions) {
+				t.Fatalf("got actions %v, want %v", actions, test.wantActions)
+			}
+		})
+	}
+}
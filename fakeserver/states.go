@@ -0,0 +1,374 @@
+package fakeserver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"vaxctl/model"
+)
+
+const (
+	pendingStatus  = "PENDING"
+	ruleTrigger    = "rule"
+	manualTrigger  = "manual"
+	openStates     = "open"
+	unknownStates  = "unknown"
+	resolvedStates = "resolved"
+)
+
+// states
+
+func (s *store) stateById(id interface{}) (model.State, error) {
+	stateId, err := strconv.Atoi(fmt.Sprint(id))
+	if err != nil {
+		return model.State{}, badRequest("state ID '%v' is not valid", id)
+	}
+	for _, state := range s.states {
+		if state.StateId == stateId {
+			return state, nil
+		}
+	}
+	return model.State{}, notFound("state", strconv.Itoa(stateId))
+}
+
+func (s *store) latestState(deviceUID string) int {
+	for idx := len(s.states) - 1; idx >= 0; idx-- {
+		if s.states[idx].DeviceUID == deviceUID {
+			return idx
+		}
+	}
+	return -1
+}
+
+func getAllStates(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	query := r.URL.Query()
+	var regex *regexp.Regexp
+	if query.Get("regex") != "" {
+		var err error
+		regex, err = regexp.Compile(query.Get("regex"))
+		if err != nil {
+			return nil, badRequest("regex is not valid: %v", err)
+		}
+	}
+	states := []model.State{}
+	for _, state := range h.store.states {
+		switch query.Get("type") {
+		case openStates:
+			if state.Resolved {
+				continue
+			}
+		case unknownStates:
+			if state.Resolved || state.MatchedRule != "" {
+				continue
+			}
+		case resolvedStates:
+			if !state.Resolved {
+				continue
+			}
+		}
+		if query.Get("uid") != "" && state.DeviceUID != query.Get("uid") {
+			continue
+		}
+		if regex != nil && !regex.MatchString(state.OcrText) {
+			continue
+		}
+		states = append(states, state)
+	}
+	return model.StatesResponse{States: states}, nil
+}
+
+func getState(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	state, err := h.store.stateById(r.URL.Query().Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	return model.StatesResponse{States: []model.State{state}}, nil
+}
+
+// createOrUpdateState refreshes the device's open state when the screenshot
+// did not change, otherwise a new state is created and matched against the
+// enabled rules (in order). Zombie devices get work assigned for a match.
+func createOrUpdateState(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "device_uid", "screenshot")
+	if err != nil {
+		return nil, err
+	}
+	var newState model.State
+	err = fromMap(body, nil, &newState)
+	if err != nil {
+		return nil, err
+	}
+	deviceIdx := h.store.findDevice(newState.DeviceUID)
+	if deviceIdx == -1 {
+		return nil, notFound("device", newState.DeviceUID)
+	}
+
+	latestIdx := h.store.latestState(newState.DeviceUID)
+	if latestIdx != -1 {
+		latest := &h.store.states[latestIdx]
+		if !latest.Resolved && latest.Screenshot == newState.Screenshot {
+			latest.Resolved = newState.Resolved
+			latest.LastUpdated = timestamp()
+			return model.StatesResponse{States: []model.State{*latest}}, nil
+		}
+		latest.Resolved = true
+	}
+
+	newState.StateId = h.store.nextStateId
+	h.store.nextStateId++
+	newState.CreatedAt = timestamp()
+	newState.LastUpdated = newState.CreatedAt
+	newState.MatchedRule = ""
+	var matchedRule *model.Rule
+	if !newState.Resolved {
+		for idx, rule := range h.store.rules {
+			regex, err := compileRuleRegex(rule)
+			if err == nil && rule.Enabled && regex.MatchString(newState.OcrText) {
+				matchedRule = &h.store.rules[idx]
+				newState.MatchedRule = rule.Name
+				break
+			}
+		}
+	}
+	h.store.states = append(h.store.states, newState)
+
+	if matchedRule != nil && h.store.devices[deviceIdx].Zombie && h.store.pendingWork(newState.DeviceUID) == -1 {
+		h.store.addWork(newState.DeviceUID, newState.StateId, matchedRule.Actions, ruleTrigger)
+	}
+	return model.StatesResponse{States: []model.State{newState}}, nil
+}
+
+func resolveState(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	uid := r.URL.Query().Get("uid")
+	idx := h.store.latestState(uid)
+	if idx == -1 || h.store.states[idx].Resolved {
+		return nil, notFound("open state for device", uid)
+	}
+	h.store.states[idx].Resolved = true
+	h.store.states[idx].LastUpdated = timestamp()
+	return model.StatesResponse{States: []model.State{h.store.states[idx]}}, nil
+}
+
+func updateResolvedState(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "state_id")
+	if err != nil {
+		return nil, err
+	}
+	state, err := h.store.stateById(body["state_id"])
+	if err != nil {
+		return nil, err
+	}
+	for idx := range h.store.states {
+		if h.store.states[idx].StateId == state.StateId {
+			h.store.states[idx].Resolved, _ = body["resolved"].(bool)
+			h.store.states[idx].LastUpdated = timestamp()
+			state = h.store.states[idx]
+		}
+	}
+	return model.StatesResponse{States: []model.State{state}}, nil
+}
+
+// screenshots
+
+func decodeScreenshot(screenshot string) ([]byte, error) {
+	if screenshot == "" {
+		return nil, notFound("screenshot", "")
+	}
+	return base64.StdEncoding.DecodeString(screenshot)
+}
+
+func getScreenshotByStateId(h *Handler, r *http.Request) ([]byte, error) {
+	state, err := h.store.stateById(r.URL.Query().Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	return decodeScreenshot(state.Screenshot)
+}
+
+func getScreenshotByDevice(h *Handler, r *http.Request) ([]byte, error) {
+	uid := r.URL.Query().Get("uid")
+	idx := h.store.latestState(uid)
+	if idx == -1 {
+		return nil, notFound("state for device", uid)
+	}
+	return decodeScreenshot(h.store.states[idx].Screenshot)
+}
+
+func getScreenshotByRule(h *Handler, r *http.Request) ([]byte, error) {
+	name := r.URL.Query().Get("name")
+	idx := h.store.findRule(name)
+	if idx == -1 {
+		return nil, notFound("rule", name)
+	}
+	return decodeScreenshot(h.store.rules[idx].Screenshot)
+}
+
+// works
+
+func (s *store) pendingWork(deviceUID string) int {
+	for idx, work := range s.works {
+		if work.DeviceUID == deviceUID && work.Status == pendingStatus {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (s *store) addWork(deviceUID string, stateId int, actionNames []string, trigger string) model.Work {
+	var actions []model.Action
+	for _, actionName := range actionNames {
+		if idx := s.findAction(actionName); idx != -1 {
+			actions = append(actions, s.actions[idx])
+		}
+	}
+	work := model.Work{
+		Id:          s.nextWorkId,
+		StateId:     stateId,
+		DeviceUID:   deviceUID,
+		Actions:     actions,
+		Trigger:     trigger,
+		Assigned:    timestamp(),
+		Status:      pendingStatus,
+		CreatedAt:   timestamp(),
+		LastUpdated: timestamp(),
+	}
+	s.nextWorkId++
+	s.works = append(s.works, work)
+	return work
+}
+
+func (s *store) workById(id string) (int, error) {
+	workId, err := strconv.Atoi(id)
+	if err != nil {
+		return -1, badRequest("work ID '%v' is not valid", id)
+	}
+	for idx, work := range s.works {
+		if work.Id == workId {
+			return idx, nil
+		}
+	}
+	return -1, notFound("work", id)
+}
+
+func getAllWorks(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	return model.WorksResponse{Works: append([]model.Work{}, h.store.works...)}, nil
+}
+
+func getWorksByDevice(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	uid := r.URL.Query().Get("uid")
+	if h.store.findDevice(uid) == -1 {
+		return nil, notFound("device", uid)
+	}
+	works := []model.Work{}
+	for _, work := range h.store.works {
+		if work.DeviceUID == uid {
+			works = append(works, work)
+		}
+	}
+	return model.WorksResponse{Works: works}, nil
+}
+
+func getWorkByID(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	idx, err := h.store.workById(r.URL.Query().Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	return model.WorksResponse{Works: []model.Work{h.store.works[idx]}}, nil
+}
+
+func assignWork(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "device_uid")
+	if err != nil {
+		return nil, err
+	}
+	var assignment model.WorkAssignment
+	err = fromMap(body, nil, &assignment)
+	if err != nil {
+		return nil, err
+	}
+	if h.store.findDevice(assignment.DeviceUID) == -1 {
+		return nil, notFound("device", assignment.DeviceUID)
+	}
+	actionNames := assignment.Actions
+	if assignment.Rule != "" {
+		ruleIdx := h.store.findRule(assignment.Rule)
+		if ruleIdx == -1 {
+			return nil, notFound("rule", assignment.Rule)
+		}
+		actionNames = h.store.rules[ruleIdx].Actions
+	}
+	if len(actionNames) == 0 {
+		return nil, badRequest("either a rule or a list of actions must be set")
+	}
+	for _, actionName := range actionNames {
+		if h.store.findAction(actionName) == -1 {
+			return nil, notFound("action", actionName)
+		}
+	}
+	stateId := 0
+	if stateIdx := h.store.latestState(assignment.DeviceUID); stateIdx != -1 {
+		stateId = h.store.states[stateIdx].StateId
+	}
+	work := h.store.addWork(assignment.DeviceUID, stateId, actionNames, manualTrigger)
+	return model.WorksResponse{Works: []model.Work{work}}, nil
+}
+
+func completeWork(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "work_id", "status")
+	if err != nil {
+		return nil, err
+	}
+	idx, err := h.store.workById(fmt.Sprint(body["work_id"]))
+	if err != nil {
+		return nil, err
+	}
+	work := &h.store.works[idx]
+	if work.Status != pendingStatus {
+		return nil, badRequest("work '%d' is not pending", work.Id)
+	}
+	work.Status = strings.ToUpper(stringField(body, "status"))
+	work.LastUpdated = timestamp()
+	return model.WorksResponse{Works: []model.Work{*work}}, nil
+}
+
+// executions
+
+func getExecutionsByWork(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	idx, err := h.store.workById(r.URL.Query().Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	executions := []model.Execution{}
+	for _, execution := range h.store.executions {
+		if execution.WorkId == h.store.works[idx].Id {
+			executions = append(executions, execution)
+		}
+	}
+	return model.ExecutionsResponse{Executions: executions}, nil
+}
+
+func createExecution(h *Handler, r *http.Request, body map[string]interface{}) (interface{}, error) {
+	err := requireFields(body, "work_id", "status")
+	if err != nil {
+		return nil, err
+	}
+	var execution model.Execution
+	err = fromMap(body, nil, &execution)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := h.store.workById(strconv.Itoa(execution.WorkId))
+	if err != nil {
+		return nil, err
+	}
+	execution.Id = h.store.nextExecutionId
+	h.store.nextExecutionId++
+	execution.StateId = h.store.works[idx].StateId
+	execution.CreatedAt = timestamp()
+	execution.LastUpdated = execution.CreatedAt
+	h.store.executions = append(h.store.executions, execution)
+	return model.ExecutionsResponse{Executions: []model.Execution{execution}}, nil
+}
@@ -0,0 +1,162 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"golang.org/x/term"
+)
+
+const diffContextLines = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff returns the lines of a unified diff between the two texts, no
+// lines are returned when they are equal.
+func UnifiedDiff(fromName string, toName string, from string, to string) []string {
+	if from == to {
+		return nil
+	}
+	lines := diffLines(splitLines(from), splitLines(to))
+	output := []string{"--- " + fromName, "+++ " + toName}
+
+	for start := 0; start < len(lines); {
+		for start < len(lines) && lines[start].op == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		// extend the hunk until there are more than two context blocks of equal lines
+		hunkStart := maxInt(start-diffContextLines, 0)
+		hunkEnd := start
+		for equal := 0; hunkEnd < len(lines) && equal <= 2*diffContextLines; hunkEnd++ {
+			if lines[hunkEnd].op == ' ' {
+				equal++
+			} else {
+				equal = 0
+			}
+		}
+		for hunkEnd > start && lines[hunkEnd-1].op == ' ' {
+			hunkEnd--
+		}
+		hunkEnd = minInt(hunkEnd+diffContextLines, len(lines))
+
+		fromLine, toLine := 1, 1
+		for _, line := range lines[:hunkStart] {
+			if line.op != '+' {
+				fromLine++
+			}
+			if line.op != '-' {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		var hunk []string
+		for _, line := range lines[hunkStart:hunkEnd] {
+			if line.op != '+' {
+				fromCount++
+			}
+			if line.op != '-' {
+				toCount++
+			}
+			hunk = append(hunk, string(line.op)+line.text)
+		}
+		output = append(output, fmt.Sprintf("@@ -%s +%s @@", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount)))
+		output = append(output, hunk...)
+		start = hunkEnd
+	}
+	return output
+}
+
+// PrintDiff prints the diff lines, colored when stdout is a terminal.
+func PrintDiff(lines []string) {
+	colored := term.IsTerminal(int(os.Stdout.Fd()))
+	for _, line := range lines {
+		if colored {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				line = text.Bold.Sprint(line)
+			case strings.HasPrefix(line, "@@"):
+				line = text.FgCyan.Sprint(line)
+			case strings.HasPrefix(line, "+"):
+				line = text.FgGreen.Sprint(line)
+			case strings.HasPrefix(line, "-"):
+				line = text.FgRed.Sprint(line)
+			}
+		}
+		fmt.Println(line)
+	}
+}
+
+// diffLines finds the longest common subsequence of the lines and returns
+// the edit script between them.
+func diffLines(from []string, to []string) []diffLine {
+	common := make([][]int, len(from)+1)
+	for idx := range common {
+		common[idx] = make([]int, len(to)+1)
+	}
+	for fromIdx := len(from) - 1; fromIdx >= 0; fromIdx-- {
+		for toIdx := len(to) - 1; toIdx >= 0; toIdx-- {
+			if from[fromIdx] == to[toIdx] {
+				common[fromIdx][toIdx] = common[fromIdx+1][toIdx+1] + 1
+			} else {
+				common[fromIdx][toIdx] = maxInt(common[fromIdx+1][toIdx], common[fromIdx][toIdx+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	fromIdx, toIdx := 0, 0
+	for fromIdx < len(from) || toIdx < len(to) {
+		switch {
+		case fromIdx < len(from) && toIdx < len(to) && from[fromIdx] == to[toIdx]:
+			lines = append(lines, diffLine{' ', from[fromIdx]})
+			fromIdx++
+			toIdx++
+		case toIdx == len(to) || (fromIdx < len(from) && common[fromIdx+1][toIdx] >= common[fromIdx][toIdx+1]):
+			lines = append(lines, diffLine{'-', from[fromIdx]})
+			fromIdx++
+		default:
+			lines = append(lines, diffLine{'+', to[toIdx]})
+			toIdx++
+		}
+	}
+	return lines
+}
+
+func splitLines(data string) []string {
+	if data == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(data, "\n"), "\n")
+}
+
+func hunkRange(line int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -15,6 +15,10 @@ type PropInfo struct {
 	Constraints  map[string]interface{}
 	Mandatory    bool
 	ItemsData    map[string]interface{}
+	// Exclusive lists the props (including this one) of which only one can be set
+	Exclusive []string
+	// ExclusiveRequired is set when one of the Exclusive props must be set
+	ExclusiveRequired bool
 }
 
 func GenerateProp(propInfo PropInfo, indent string, mandatoryFlag bool, commentsFlag bool) string {
@@ -48,3 +48,12 @@ func EncodeToYaml(data interface{}) ([]byte, error) {
 	}
 	return b.Bytes(), nil
 }
+
+func StringInSlice(value string, list []string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	KeystrokeComboSeparator = ";"
+	KeystrokeKeySeparator   = "+"
+	SpecialKeyPrefix        = "Keys."
+)
+
+// KeystrokeCombo is either a text typed key by key or a combination of keys
+// pressed at once (when any of its keys is a special key).
+type KeystrokeCombo struct {
+	Text     string
+	Keys     []KeystrokeKey
+	Position int
+}
+
+// KeystrokeKey is a single character or a special key (without its prefix).
+type KeystrokeKey struct {
+	Name     string
+	Special  bool
+	Position int
+}
+
+// KeystrokeError points at the position (1-based, in characters) of the
+// action data the error was found at.
+type KeystrokeError struct {
+	Position int
+	Message  string
+}
+
+func (e *KeystrokeError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Position, e.Message)
+}
+
+func (c KeystrokeCombo) IsCombination() bool {
+	return len(c.Keys) > 0
+}
+
+func (c KeystrokeCombo) String() string {
+	if !c.IsCombination() {
+		return c.Text
+	}
+	var keys []string
+	for _, key := range c.Keys {
+		keys = append(keys, key.String())
+	}
+	return strings.Join(keys, KeystrokeKeySeparator)
+}
+
+func (k KeystrokeKey) String() string {
+	if k.Special {
+		return SpecialKeyPrefix + k.Name
+	}
+	return k.Name
+}
+
+// ParseKeystrokes parses keystroke action data, a ';' separated list of
+// combos, and returns the first syntax error.
+func ParseKeystrokes(data string) ([]KeystrokeCombo, error) {
+	combos, errs := parseKeystrokes(data)
+	if len(errs) > 0 {
+		return combos, errs[0]
+	}
+	return combos, nil
+}
+
+// ParseKeystrokeCombo parses a single combo (e.g. an item of an editor).
+func ParseKeystrokeCombo(data string) (KeystrokeCombo, error) {
+	if strings.Contains(data, KeystrokeComboSeparator) {
+		return KeystrokeCombo{}, &KeystrokeError{Position: strings.Index(data, KeystrokeComboSeparator) + 1, Message: "a combo can't contain ';'"}
+	}
+	combos, err := ParseKeystrokes(data)
+	if len(combos) == 0 {
+		return KeystrokeCombo{}, err
+	}
+	return combos[0], err
+}
+
+// ValidateKeystrokes returns all syntax errors of the action data and the
+// special keys that are not one of the given ones.
+func ValidateKeystrokes(data string, specialKeys []string) []error {
+	combos, errs := parseKeystrokes(data)
+	for _, combo := range combos {
+		for _, key := range combo.Keys {
+			if !key.Special || StringInSlice(key.Name, specialKeys) {
+				continue
+			}
+			message := fmt.Sprintf("unknown special key '%s'", key)
+			if canonicalKey := canonicalSpecialKey(key.Name, specialKeys); canonicalKey != "" {
+				message += fmt.Sprintf(" (did you mean '%s%s'?)", SpecialKeyPrefix, canonicalKey)
+			}
+			errs = append(errs, &KeystrokeError{Position: key.Position, Message: message})
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].(*KeystrokeError).Position < errs[j].(*KeystrokeError).Position
+	})
+	return errs
+}
+
+// FormatKeystrokes returns the canonical form of the combos: keys of
+// combinations without surrounding spaces and special keys spelled like the
+// given ones.
+func FormatKeystrokes(combos []KeystrokeCombo, specialKeys []string) string {
+	var formatted []string
+	for _, combo := range combos {
+		for idx, key := range combo.Keys {
+			if canonicalKey := canonicalSpecialKey(key.Name, specialKeys); key.Special && canonicalKey != "" {
+				combo.Keys[idx].Name = canonicalKey
+			}
+		}
+		formatted = append(formatted, combo.String())
+	}
+	return strings.Join(formatted, KeystrokeComboSeparator)
+}
+
+func canonicalSpecialKey(name string, specialKeys []string) string {
+	for _, specialKey := range specialKeys {
+		if strings.EqualFold(name, specialKey) {
+			return specialKey
+		}
+	}
+	return ""
+}
+
+func parseKeystrokes(data string) ([]KeystrokeCombo, []error) {
+	position := func(offset int) int {
+		return utf8.RuneCountInString(data[:offset]) + 1
+	}
+	if data == "" {
+		return nil, []error{&KeystrokeError{Position: 1, Message: "no combos are set"}}
+	}
+	var combos []KeystrokeCombo
+	var errs []error
+	offset := 0
+	for _, text := range strings.Split(data, KeystrokeComboSeparator) {
+		comboOffset := offset
+		offset += len(text) + len(KeystrokeComboSeparator)
+		if text == "" {
+			errs = append(errs, &KeystrokeError{Position: position(comboOffset), Message: "empty combo"})
+			continue
+		}
+		combo := KeystrokeCombo{Text: text, Position: position(comboOffset)}
+		parts := strings.Split(text, KeystrokeKeySeparator)
+		isCombination := false
+		for _, part := range parts {
+			if strings.HasPrefix(strings.TrimSpace(part), SpecialKeyPrefix) {
+				isCombination = true
+			}
+		}
+		if !isCombination {
+			combos = append(combos, combo)
+			continue
+		}
+		partOffset := comboOffset
+		for _, part := range parts {
+			keyOffset := partOffset + len(part) - len(strings.TrimLeft(part, " \t"))
+			partOffset += len(part) + len(KeystrokeKeySeparator)
+			keyName := strings.TrimSpace(part)
+			key := KeystrokeKey{Name: keyName, Position: position(keyOffset)}
+			if strings.HasPrefix(keyName, SpecialKeyPrefix) {
+				key.Name = strings.TrimPrefix(keyName, SpecialKeyPrefix)
+				key.Special = true
+			}
+			var message string
+			switch {
+			case keyName == "":
+				message = "empty key in combo"
+			case key.Special && key.Name == "":
+				message = fmt.Sprintf("missing special key name after '%s'", SpecialKeyPrefix)
+			case !key.Special && utf8.RuneCountInString(keyName) != 1:
+				message = fmt.Sprintf("'%s' is not a single character (special keys must start with '%s')", keyName, SpecialKeyPrefix)
+			}
+			for _, otherKey := range combo.Keys {
+				if message == "" && otherKey.String() == key.String() {
+					message = fmt.Sprintf("'%s' is pressed twice", keyName)
+				}
+			}
+			if message != "" {
+				errs = append(errs, &KeystrokeError{Position: key.Position, Message: message})
+				continue
+			}
+			combo.Keys = append(combo.Keys, key)
+		}
+		combos = append(combos, combo)
+	}
+	return combos, errs
+}
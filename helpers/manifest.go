@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const StdinFilename = "-"
+
+// Document is a single JSON document read from a (possibly multi-document)
+// YAML or JSON file, Err is set when the document could not be converted.
+type Document struct {
+	Source string
+	Index  int
+	Data   []byte
+	Err    error
+}
+
+var (
+	documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+	manifestSuffixes  = []string{".yaml", ".yml", ".json"}
+)
+
+// ReadDocuments reads all documents from the given files, directories (only
+// non-hidden YAML/JSON files are read, in name order) or stdin when the path
+// is '-'. When set, render is called with the content of every file before
+// it's split to documents.
+func ReadDocuments(paths []string, recursive bool, render func(source string, data []byte) ([]byte, error)) ([]Document, error) {
+	var documents []Document
+	for _, path := range paths {
+		filenames, err := expandManifestPath(path, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, filename := range filenames {
+			var data []byte
+			if filename == StdinFilename {
+				data, err = ioutil.ReadAll(bufio.NewReader(os.Stdin))
+			} else {
+				data, err = ioutil.ReadFile(filename)
+			}
+			if err == nil && render != nil {
+				data, err = render(filename, data)
+			}
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, SplitDocuments(filename, data)...)
+		}
+	}
+	return documents, nil
+}
+
+// SplitDocuments splits the data on '---' separators and converts each
+// document to JSON, empty documents are skipped.
+func SplitDocuments(source string, data []byte) []Document {
+	var documents []Document
+	for _, documentData := range documentSeparator.Split(string(data), -1) {
+		if isEmptyDocument(documentData) {
+			continue
+		}
+		jsonData, err := ToJSON([]byte(documentData))
+		if err == nil && string(jsonData) == "null" {
+			continue
+		}
+		documents = append(documents, Document{Source: source, Index: len(documents), Data: jsonData, Err: err})
+	}
+	return documents
+}
+
+func expandManifestPath(path string, recursive bool) ([]string, error) {
+	if path == StdinFilename {
+		return []string{path}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var filenames []string
+	err = filepath.Walk(path, func(walkPath string, walkInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// hidden files (e.g. sync inventories) and directories are skipped
+		hidden := walkPath != path && strings.HasPrefix(walkInfo.Name(), ".")
+		if walkInfo.IsDir() {
+			if walkPath != path && (!recursive || hidden) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hidden {
+			return nil
+		}
+		if isManifestFile(walkPath) {
+			filenames = append(filenames, walkPath)
+		}
+		return nil
+	})
+	sort.Strings(filenames)
+	return filenames, err
+}
+
+func isManifestFile(filename string) bool {
+	for _, suffix := range manifestSuffixes {
+		if strings.HasSuffix(strings.ToLower(filename), suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyDocument(data string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 && line[0] != '#' {
+			return false
+		}
+	}
+	return true
+}
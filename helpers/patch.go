@@ -0,0 +1,271 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MergePatch applies a JSON merge patch (RFC 7386) to a JSON document.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var documentValue, patchValue interface{}
+	err := json.Unmarshal(document, &documentValue)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(patch, &patchValue)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatchValue(documentValue, patchValue))
+}
+
+func mergePatchValue(target interface{}, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = map[string]interface{}{}
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(targetMap, key)
+		} else {
+			targetMap[key] = mergePatchValue(targetMap[key], value)
+		}
+	}
+	return targetMap
+}
+
+// JSONPatch applies a JSON patch (RFC 6902) to a JSON document, the
+// operations are applied in order and the first failed one is returned.
+func JSONPatch(document []byte, patch []byte) ([]byte, error) {
+	var documentValue interface{}
+	err := json.Unmarshal(document, &documentValue)
+	if err != nil {
+		return nil, err
+	}
+	var operations []map[string]interface{}
+	err = json.Unmarshal(patch, &operations)
+	if err != nil {
+		return nil, fmt.Errorf("a JSON patch must be a list of operations: %v", err)
+	}
+	for idx, operation := range operations {
+		documentValue, err = applyPatchOperation(documentValue, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %v", idx+1, err)
+		}
+	}
+	return json.Marshal(documentValue)
+}
+
+func applyPatchOperation(document interface{}, operation map[string]interface{}) (interface{}, error) {
+	op, _ := operation["op"].(string)
+	path, ok := operation["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("'path' is missing")
+	}
+	value, hasValue := operation["value"]
+	from, hasFrom := operation["from"].(string)
+	switch op {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, fmt.Errorf("'value' is missing")
+		}
+	case "move", "copy":
+		if !hasFrom {
+			return nil, fmt.Errorf("'from' is missing")
+		}
+	}
+	switch op {
+	case "add":
+		return addPointerValue(document, path, value)
+	case "remove":
+		document, _, err := removePointerValue(document, path)
+		return document, err
+	case "replace":
+		if path == "" {
+			return value, nil
+		}
+		document, _, err := removePointerValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return addPointerValue(document, path, value)
+	case "move":
+		if strings.HasPrefix(path, from+"/") {
+			return nil, fmt.Errorf("'%s' can't be moved into itself", from)
+		}
+		document, moved, err := removePointerValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addPointerValue(document, path, moved)
+	case "copy":
+		copied, err := getPointerValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		data, _ := json.Marshal(copied)
+		json.Unmarshal(data, &copied)
+		return addPointerValue(document, path, copied)
+	case "test":
+		current, err := getPointerValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed, '%s' is not %s", path, mustMarshal(value))
+		}
+		return document, nil
+	}
+	return nil, fmt.Errorf("unknown op '%s' (allowed values are: add, remove, replace, move, copy & test)", op)
+}
+
+func mustMarshal(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// splitPointer returns the unescaped tokens of a JSON pointer (RFC 6901).
+func splitPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path '%s' must start with '/'", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for idx, token := range tokens {
+		tokens[idx] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, appending bool) (int, error) {
+	if token == "-" && appending {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("'%s' is not an array index", token)
+	}
+	if index > length || (index == length && !appending) {
+		return 0, fmt.Errorf("index %d is out of range", index)
+	}
+	return index, nil
+}
+
+func getPointerValue(document interface{}, path string) (interface{}, error) {
+	tokens, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	current := document
+	for _, token := range tokens {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("path '%s' was not found", path)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, fmt.Errorf("path '%s': %v", path, err)
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("path '%s' was not found", path)
+		}
+	}
+	return current, nil
+}
+
+// setPointerParent calls the function with the parent container of the path
+// and the last token, the returned container replaces the parent.
+func setPointerParent(document interface{}, path string, call func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	tokens, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return call(nil, "")
+	}
+	parentPath := path[:strings.LastIndex(path, "/")]
+	parent, err := getPointerValue(document, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	parent, err = call(parent, tokens[len(tokens)-1])
+	if err != nil {
+		return nil, fmt.Errorf("path '%s': %v", path, err)
+	}
+	if parentPath == "" {
+		return parent, nil
+	}
+	return setPointerParent(document, parentPath, func(grandParent interface{}, token string) (interface{}, error) {
+		switch container := grandParent.(type) {
+		case map[string]interface{}:
+			container[token] = parent
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container), false)
+			container[index] = parent
+		}
+		return grandParent, nil
+	})
+}
+
+func addPointerValue(document interface{}, path string, value interface{}) (interface{}, error) {
+	return setPointerParent(document, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case nil:
+			return value, nil
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("parent is not an object or an array")
+	})
+}
+
+func removePointerValue(document interface{}, path string) (interface{}, interface{}, error) {
+	var removed interface{}
+	document, err := setPointerParent(document, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case nil:
+			return nil, fmt.Errorf("the whole document can't be removed")
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("not found")
+			}
+			removed = value
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[index]
+			return append(container[:index:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("parent is not an object or an array")
+	})
+	return document, removed, err
+}
@@ -0,0 +1,216 @@
+package helpers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func checkPatchResult(t *testing.T, got []byte, err error, want string, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("got error %v, want %q", err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var gotValue, wantValue interface{}
+	json.Unmarshal(got, &gotValue)
+	json.Unmarshal([]byte(want), &wantValue)
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "escaped tokens",
+			document: `{"a/b":1,"m~n":2,"~1":3}`,
+			patch:    `[{"op":"replace","path":"/a~1b","value":10},{"op":"remove","path":"/m~0n"},{"op":"test","path":"/~01","value":3}]`,
+			want:     `{"a/b":10,"~1":3}`,
+		},
+		{
+			name:     "append with -",
+			document: `{"actions":["press-f1"]}`,
+			patch:    `[{"op":"add","path":"/actions/-","value":"wait"}]`,
+			want:     `{"actions":["press-f1","wait"]}`,
+		},
+		{
+			name:     "insert before an index",
+			document: `{"actions":["press-f1","wait"]}`,
+			patch:    `[{"op":"add","path":"/actions/1","value":"sleep"}]`,
+			want:     `{"actions":["press-f1","sleep","wait"]}`,
+		},
+		{
+			name:     "add past the end",
+			document: `{"actions":["press-f1"]}`,
+			patch:    `[{"op":"add","path":"/actions/2","value":"wait"}]`,
+			wantErr:  "index 2 is out of range",
+		},
+		{
+			name:     "remove past the end",
+			document: `{"actions":["press-f1"]}`,
+			patch:    `[{"op":"remove","path":"/actions/1"}]`,
+			wantErr:  "index 1 is out of range",
+		},
+		{
+			name:     "remove with -",
+			document: `{"actions":["press-f1"]}`,
+			patch:    `[{"op":"remove","path":"/actions/-"}]`,
+			wantErr:  "'-' is not an array index",
+		},
+		{
+			name:     "leading zero index",
+			document: `{"actions":["press-f1","wait"]}`,
+			patch:    `[{"op":"replace","path":"/actions/01","value":"sleep"}]`,
+			wantErr:  "'01' is not an array index",
+		},
+		{
+			name:     "negative index",
+			document: `{"actions":["press-f1"]}`,
+			patch:    `[{"op":"remove","path":"/actions/-1"}]`,
+			wantErr:  "'-1' is not an array index",
+		},
+		{
+			name:     "move into its own child",
+			document: `{"a":{"b":1}}`,
+			patch:    `[{"op":"move","from":"/a","path":"/a/c"}]`,
+			wantErr:  "'/a' can't be moved into itself",
+		},
+		{
+			name:     "move to a sibling with a common prefix",
+			document: `{"a":1}`,
+			patch:    `[{"op":"move","from":"/a","path":"/ab"}]`,
+			want:     `{"ab":1}`,
+		},
+		{
+			name:     "move within an array",
+			document: `{"actions":["a","b","c"]}`,
+			patch:    `[{"op":"move","from":"/actions/0","path":"/actions/-"}]`,
+			want:     `{"actions":["b","c","a"]}`,
+		},
+		{
+			name:     "copy is a deep copy",
+			document: `{"a":{"b":1}}`,
+			patch:    `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:     `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:     "replace the root",
+			document: `{"a":1}`,
+			patch:    `[{"op":"replace","path":"","value":{"b":2}}]`,
+			want:     `{"b":2}`,
+		},
+		{
+			name:     "remove the root",
+			document: `{"a":1}`,
+			patch:    `[{"op":"remove","path":""}]`,
+			wantErr:  "the whole document can't be removed",
+		},
+		{
+			name:     "replace a missing key",
+			document: `{"a":1}`,
+			patch:    `[{"op":"replace","path":"/b","value":2}]`,
+			wantErr:  "path '/b': not found",
+		},
+		{
+			name:     "insert into a nested array",
+			document: `{"a":[[1,2],[3]]}`,
+			patch:    `[{"op":"add","path":"/a/0/1","value":9},{"op":"add","path":"/a/1/-","value":4}]`,
+			want:     `{"a":[[1,9,2],[3,4]]}`,
+		},
+		{
+			name:     "remove from an array in an object in an array",
+			document: `[{"actions":["a","b"]},{"actions":["c"]}]`,
+			patch:    `[{"op":"remove","path":"/0/actions/0"},{"op":"add","path":"/1/actions/0","value":"b"}]`,
+			want:     `[{"actions":["b"]},{"actions":["b","c"]}]`,
+		},
+		{
+			name:     "missing parent",
+			document: `{"a":1}`,
+			patch:    `[{"op":"add","path":"/b/c","value":2}]`,
+			wantErr:  "path '/b' was not found",
+		},
+		{
+			name:     "failed test stops the patch",
+			document: `{"a":1}`,
+			patch:    `[{"op":"add","path":"/b","value":2},{"op":"test","path":"/a","value":2}]`,
+			wantErr:  "operation 2: test failed, '/a' is not 2",
+		},
+		{
+			name:     "relative path",
+			document: `{"a":1}`,
+			patch:    `[{"op":"remove","path":"a"}]`,
+			wantErr:  "path 'a' must start with '/'",
+		},
+		{
+			name:     "unknown op",
+			document: `{"a":1}`,
+			patch:    `[{"op":"merge","path":"/a"}]`,
+			wantErr:  "unknown op 'merge'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(test.document), []byte(test.patch))
+			checkPatchResult(t, got, err, test.want, test.wantErr)
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+	}{
+		{
+			name:     "null deletes keys",
+			document: `{"a":1,"metadata":{"rack":"A1","row":"2"}}`,
+			patch:    `{"a":null,"metadata":{"rack":null,"slot":"4"}}`,
+			want:     `{"metadata":{"row":"2","slot":"4"}}`,
+		},
+		{
+			name:     "null of a missing key",
+			document: `{"a":1}`,
+			patch:    `{"b":null}`,
+			want:     `{"a":1}`,
+		},
+		{
+			name:     "arrays are replaced",
+			document: `{"actions":["a","b"]}`,
+			patch:    `{"actions":["c"]}`,
+			want:     `{"actions":["c"]}`,
+		},
+		{
+			name:     "object replaces a scalar",
+			document: `{"a":1}`,
+			patch:    `{"a":{"b":null,"c":2}}`,
+			want:     `{"a":{"c":2}}`,
+		},
+		{
+			name:     "non object patch replaces the document",
+			document: `{"a":1}`,
+			patch:    `["a"]`,
+			want:     `["a"]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(test.document), []byte(test.patch))
+			checkPatchResult(t, got, err, test.want, "")
+		})
+	}
+}
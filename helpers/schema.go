@@ -0,0 +1,154 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const jsonSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// GenerateSchema converts the props of a resource to a JSON Schema, the
+// 'kind' property of manifests (any of the kind names) is allowed as well.
+func GenerateSchema(title string, kindNames []string, props []PropInfo) map[string]interface{} {
+	properties := map[string]interface{}{
+		"kind": map[string]interface{}{
+			"type":        "string",
+			"description": "kind of the resource (used by 'vaxctl apply -f')",
+			"enum":        kindNames,
+		},
+	}
+	var required []string
+	var exclusions []interface{}
+	excluded := map[string]bool{}
+	for _, prop := range props {
+		properties[prop.Name] = propSchema(prop)
+		if prop.Mandatory {
+			required = append(required, prop.Name)
+		}
+		group := strings.Join(prop.Exclusive, ",")
+		if len(prop.Exclusive) > 1 && !excluded[group] {
+			excluded[group] = true
+			for idx, name := range prop.Exclusive {
+				for _, otherName := range prop.Exclusive[idx+1:] {
+					exclusions = append(exclusions, map[string]interface{}{
+						"not": map[string]interface{}{"required": []string{name, otherName}},
+					})
+				}
+			}
+			if prop.ExclusiveRequired {
+				var alternatives []interface{}
+				for _, name := range prop.Exclusive {
+					alternatives = append(alternatives, map[string]interface{}{"required": []string{name}})
+				}
+				exclusions = append(exclusions, map[string]interface{}{"anyOf": alternatives})
+			}
+		}
+	}
+	schema := map[string]interface{}{
+		"$schema":    jsonSchemaVersion,
+		"title":      title,
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if len(exclusions) > 0 {
+		schema["allOf"] = exclusions
+	}
+	return schema
+}
+
+func propSchema(prop PropInfo) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":        prop.Type,
+		"description": prop.Desc,
+	}
+	if prop.DefaultValue != nil && prop.Type != "object" {
+		schema["default"] = prop.DefaultValue
+	}
+	if enum, ok := prop.Constraints["enum"]; ok {
+		schema["enum"] = enum
+	}
+	if prop.ItemsData != nil {
+		schema["items"] = prop.ItemsData
+	}
+	return schema
+}
+
+// ValidateProps checks the fields of a resource against its props: the
+// mandatory props, their types and enums and the exclusive props (exactly
+// one must be set when they're required). Fields without a prop are accepted.
+func ValidateProps(props []PropInfo, fields map[string]interface{}) []error {
+	var errs []error
+	for _, prop := range props {
+		value, ok := fields[prop.Name]
+		if !ok || value == nil {
+			if prop.Mandatory {
+				errs = append(errs, fmt.Errorf("'%s' is mandatory", prop.Name))
+			}
+			continue
+		}
+		err := validateValue(prop.Type, prop.Constraints["enum"], value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'%s' %v", prop.Name, err))
+			continue
+		}
+		if items, ok := value.([]interface{}); ok && prop.ItemsData != nil {
+			itemType, _ := prop.ItemsData["type"].(string)
+			for idx, item := range items {
+				err = validateValue(itemType, prop.ItemsData["enum"], item)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("'%s[%d]' %v", prop.Name, idx, err))
+				}
+			}
+		}
+	}
+	reported := map[string]bool{}
+	for _, prop := range props {
+		var set []string
+		for _, name := range prop.Exclusive {
+			if value, ok := fields[name]; ok && value != nil {
+				set = append(set, name)
+			}
+		}
+		sort.Strings(set)
+		key := strings.Join(set, ", ")
+		if len(set) > 1 && !reported[key] {
+			reported[key] = true
+			errs = append(errs, fmt.Errorf("only one of [%s] can be set", key))
+		}
+		group := strings.Join(prop.Exclusive, ", ")
+		if len(set) == 0 && prop.ExclusiveRequired && !reported[group] {
+			reported[group] = true
+			errs = append(errs, fmt.Errorf("one of [%s] must be set", group))
+		}
+	}
+	return errs
+}
+
+func validateValue(valueType string, enum interface{}, value interface{}) error {
+	valid := true
+	switch valueType {
+	case "string":
+		_, valid = value.(string)
+	case "integer":
+		number, ok := value.(float64)
+		valid = ok && number == math.Trunc(number)
+	case "boolean":
+		_, valid = value.(bool)
+	case "array":
+		_, valid = value.([]interface{})
+	case "object":
+		_, valid = value.(map[string]interface{})
+	}
+	if !valid {
+		return fmt.Errorf("must be of type %s", valueType)
+	}
+	if values, ok := enum.([]string); ok && len(values) > 0 && !StringInSlice(fmt.Sprint(value), values) {
+		return fmt.Errorf("must be one of [%s]", strings.Join(values, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+)
+
+// templateFuncs are the functions available to manifest templates on top of
+// the text/template builtins. There is no access to the environment, values
+// are only passed explicitly with files and settings.
+var templateFuncs = template.FuncMap{
+	"default": func(defaultValue interface{}, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return defaultValue
+		}
+		return value
+	},
+	"required": func(message string, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return nil, fmt.Errorf("%s", message)
+		}
+		return value, nil
+	},
+	"quote": func(value interface{}) string {
+		return fmt.Sprintf("%q", fmt.Sprint(value))
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// RenderTemplate renders a Go template with the values available as
+// '.Values', a missing value is an error. Optional values are read with
+// 'index' which returns nothing for missing keys, e.g.
+// '{{ index .Values "rack" | default "A1" }}'.
+func RenderTemplate(name string, data []byte, values map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	err = tmpl.Execute(&rendered, map[string]interface{}{"Values": values})
+	if err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
+
+// ReadValues merges the YAML/JSON values files (later files win) and then
+// the 'key.path=value' settings, values of settings are parsed as YAML so
+// numbers and booleans keep their types.
+func ReadValues(filenames []string, settings []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		fileValues := map[string]interface{}{}
+		err = yaml.Unmarshal(data, &fileValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse values file '%s': %v", filename, err)
+		}
+		MergeValues(values, fileValues)
+	}
+	for _, setting := range settings {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("setting '%s' is not valid (expected KEY=VALUE)", setting)
+		}
+		var value interface{}
+		if yaml.Unmarshal([]byte(parts[1]), &value) != nil || value == nil {
+			value = parts[1]
+		}
+		keys := strings.Split(parts[0], ".")
+		nested := values
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := nested[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				nested[key] = child
+			}
+			nested = child
+		}
+		nested[keys[len(keys)-1]] = value
+	}
+	return values, nil
+}
+
+// MergeValues deep merges the source values into the destination.
+func MergeValues(destination map[string]interface{}, source map[string]interface{}) {
+	for key, value := range source {
+		sourceMap, sourceIsMap := value.(map[string]interface{})
+		destinationMap, destinationIsMap := destination[key].(map[string]interface{})
+		if sourceIsMap && destinationIsMap {
+			MergeValues(destinationMap, sourceMap)
+		} else {
+			destination[key] = value
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// terminalSequences maps the escape sequences sent by terminals (xterm and
+// VT) to special keys.
+var terminalSequences = map[string]string{
+	"\x1b[A": "Up", "\x1b[B": "Down", "\x1b[C": "Right", "\x1b[D": "Left",
+	"\x1bOA": "Up", "\x1bOB": "Down", "\x1bOC": "Right", "\x1bOD": "Left",
+	"\x1b[H": "Home", "\x1bOH": "Home", "\x1b[1~": "Home", "\x1b[7~": "Home",
+	"\x1b[F": "End", "\x1bOF": "End", "\x1b[4~": "End", "\x1b[8~": "End",
+	"\x1b[2~": "Insert", "\x1b[3~": "Delete", "\x1b[5~": "PageUp", "\x1b[6~": "PageDown",
+	"\x1bOP": "F1", "\x1bOQ": "F2", "\x1bOR": "F3", "\x1bOS": "F4",
+	"\x1b[11~": "F1", "\x1b[12~": "F2", "\x1b[13~": "F3", "\x1b[14~": "F4",
+	"\x1b[15~": "F5", "\x1b[17~": "F6", "\x1b[18~": "F7", "\x1b[19~": "F8",
+	"\x1b[20~": "F9", "\x1b[21~": "F10", "\x1b[23~": "F11", "\x1b[24~": "F12",
+}
+
+// xdotoolKeys maps the X keysym names used by xdotool to special keys.
+var xdotoolKeys = map[string]string{
+	"return": "Enter", "kp_enter": "Enter", "enter": "Enter",
+	"escape": "Escape", "tab": "Tab", "backspace": "Backspace", "delete": "Delete", "insert": "Insert",
+	"home": "Home", "end": "End", "prior": "PageUp", "page_up": "PageUp", "next": "PageDown", "page_down": "PageDown",
+	"up": "Up", "down": "Down", "left": "Left", "right": "Right", "space": "Space", "caps_lock": "CapsLock",
+	"ctrl": "Control", "control": "Control", "control_l": "Control", "control_r": "Control",
+	"alt": "Alt", "alt_l": "Alt", "alt_r": "Alt", "shift": "Shift", "shift_l": "Shift", "shift_r": "Shift",
+}
+
+// DecodeTerminalInput converts the bytes a terminal sends for the input to
+// combos: printable characters are typed text, control characters and escape
+// sequences are special keys (e.g. '\x1b[12~' is 'Keys.F2').
+func DecodeTerminalInput(data string) ([]KeystrokeCombo, error) {
+	var combos []KeystrokeCombo
+	var text strings.Builder
+	press := func(keys ...KeystrokeKey) {
+		if text.Len() > 0 {
+			combos = append(combos, KeystrokeCombo{Text: text.String()})
+			text.Reset()
+		}
+		combos = append(combos, KeystrokeCombo{Keys: keys})
+	}
+	special := func(name string) KeystrokeKey {
+		return KeystrokeKey{Name: name, Special: true}
+	}
+	runes := []rune(data)
+	for idx := 0; idx < len(runes); idx++ {
+		char := runes[idx]
+		switch {
+		case char == '\r' || char == '\n':
+			press(special("Enter"))
+			if char == '\r' && idx+1 < len(runes) && runes[idx+1] == '\n' {
+				idx++
+			}
+		case char == '\t':
+			press(special("Tab"))
+		case char == '\x7f' || char == '\b':
+			press(special("Backspace"))
+		case char == '\x1b':
+			sequence := terminalSequence(runes[idx:])
+			if name, ok := terminalSequences[sequence]; ok {
+				press(special(name))
+				idx += len([]rune(sequence)) - 1
+			} else if len(sequence) > 1 && (sequence[1] == '[' || sequence[1] == 'O') {
+				return nil, fmt.Errorf("unknown escape sequence %q", sequence)
+			} else if idx+1 < len(runes) && unicode.IsPrint(runes[idx+1]) {
+				press(special("Alt"), KeystrokeKey{Name: string(runes[idx+1])})
+				idx++
+			} else {
+				press(special("Escape"))
+			}
+		case char >= '\x01' && char <= '\x1a':
+			press(special("Control"), KeystrokeKey{Name: string('a' + char - 1)})
+		case unicode.IsPrint(char):
+			if char == ';' {
+				return nil, fmt.Errorf("';' can't be typed by keystroke actions")
+			}
+			text.WriteRune(char)
+		default:
+			return nil, fmt.Errorf("character %q can't be converted to a key", char)
+		}
+	}
+	if text.Len() > 0 {
+		combos = append(combos, KeystrokeCombo{Text: text.String()})
+	}
+	return combos, nil
+}
+
+// terminalSequence returns the escape sequence at the start of the input:
+// ESC, ESC O and a letter or ESC [ up to its final character.
+func terminalSequence(runes []rune) string {
+	if len(runes) < 2 {
+		return string(runes)
+	}
+	switch runes[1] {
+	case 'O':
+		if len(runes) > 2 {
+			return string(runes[:3])
+		}
+	case '[':
+		for end := 2; end < len(runes); end++ {
+			if runes[end] >= '@' && runes[end] <= '~' {
+				return string(runes[:end+1])
+			}
+		}
+	}
+	return string(runes[:2])
+}
+
+// DecodeXdotoolKeys converts the arguments of 'xdotool key' (e.g.
+// 'ctrl+alt+Delete Return') to combos.
+func DecodeXdotoolKeys(args []string) ([]KeystrokeCombo, error) {
+	var combos []KeystrokeCombo
+	for _, arg := range args {
+		var combo KeystrokeCombo
+		for _, keyName := range strings.Split(arg, "+") {
+			if name, ok := xdotoolKeys[strings.ToLower(keyName)]; ok {
+				combo.Keys = append(combo.Keys, KeystrokeKey{Name: name, Special: true})
+			} else if len(keyName) > 1 && (keyName[0] == 'F' || keyName[0] == 'f') && strings.Trim(keyName[1:], "0123456789") == "" {
+				combo.Keys = append(combo.Keys, KeystrokeKey{Name: strings.ToUpper(keyName), Special: true})
+			} else if len([]rune(keyName)) == 1 {
+				combo.Keys = append(combo.Keys, KeystrokeKey{Name: keyName})
+			} else {
+				return nil, fmt.Errorf("unknown key '%s'", keyName)
+			}
+		}
+		combos = append(combos, combo)
+	}
+	return combos, nil
+}
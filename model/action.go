@@ -11,6 +11,10 @@ import (
 	"github.com/muesli/reflow/wrap"
 )
 
+// KnownActionTypes are the action types of the server, used when it can't
+// be asked (e.g. offline validation).
+var KnownActionTypes = []string{"sleep", "power", "ipmitool", "keystroke", "request"}
+
 type ActionsResponse struct {
 	Actions []Action `json:"actions"`
 }
@@ -217,10 +221,24 @@ List of special keys:
 }
 
 func GenerateAction(filename string, mandatoryFlag bool, commentsFlag bool) error {
-	allActionTypes, err := GetActionTypes()
+	props, err := actionProps(true)
 	if err != nil {
 		return err
 	}
+	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
+}
+
+// actionProps returns the props of an action, the action types are taken
+// from the server when online.
+func actionProps(online bool) ([]helpers.PropInfo, error) {
+	allActionTypes := KnownActionTypes
+	if online {
+		var err error
+		allActionTypes, err = GetActionTypes()
+		if err != nil {
+			return nil, err
+		}
+	}
 	actionTypeConstraint := make(map[string]interface{})
 	actionTypeConstraint["enum"] = allActionTypes
 
@@ -245,5 +263,5 @@ func GenerateAction(filename string, mandatoryFlag bool, commentsFlag bool) erro
 			Mandatory: true,
 		},
 	}
-	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
+	return props, nil
 }
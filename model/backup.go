@@ -0,0 +1,214 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"vaxctl/api"
+	"vaxctl/helpers"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	BackupManifestFilename = "manifest.yaml"
+	backupVersion          = 1
+	backupStatesDir        = "states"
+)
+
+// backupKinds are exported in dependency order, each one to its own file.
+var backupKinds = []backupKind{
+	{kind: "creds", listKey: "creds", filename: "creds.yaml", fileMode: 0600},
+	{kind: "device", listKey: "devices", filename: "devices.yaml", fileMode: 0644},
+	{kind: "action", listKey: "actions", filename: "actions.yaml", fileMode: 0644},
+	{kind: "rule", listKey: "rules", filename: "rules.yaml", fileMode: 0644},
+}
+
+type backupKind struct {
+	kind     string
+	listKey  string
+	filename string
+	fileMode os.FileMode
+}
+
+type ExportOptions struct {
+	Dir           string
+	IncludeStates bool
+}
+
+// BackupManifest describes the content of a backup directory.
+type BackupManifest struct {
+	Version    int            `json:"version"`
+	Server     string         `json:"server"`
+	ExportedAt string         `json:"exported_at"`
+	Files      []string       `json:"files"`
+	Counts     map[string]int `json:"counts"`
+	States     *BackupStates  `json:"states,omitempty"`
+}
+
+type BackupStates struct {
+	Dir   string `json:"dir"`
+	Count int    `json:"count"`
+}
+
+// Export writes all resources as re-appliable multi-document YAML files
+// (rules in their order) and a manifest, creds are written with their
+// passwords so that file is only readable by the owner.
+func Export(options ExportOptions) error {
+	err := os.MkdirAll(options.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	manifest := BackupManifest{
+		Version:    backupVersion,
+		Server:     api.BaseURL(),
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Counts:     map[string]int{},
+	}
+	for _, kind := range backupKinds {
+		resources, err := getAllResourceFields(kind)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		for idx, resource := range resources {
+			resource = NormalizeResource(resource, nil)
+			resource["kind"] = kind.kind
+			resourceYaml, err := helpers.EncodeToYaml(resource)
+			if err != nil {
+				return err
+			}
+			if idx > 0 {
+				data = append(data, []byte("---\n")...)
+			}
+			data = append(data, resourceYaml...)
+		}
+		err = ioutil.WriteFile(filepath.Join(options.Dir, kind.filename), data, kind.fileMode)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, kind.filename)
+		manifest.Counts[kind.kind] = len(resources)
+		fmt.Printf("exported %d %s resources to '%s'\n", len(resources), kind.kind, kind.filename)
+	}
+
+	if options.IncludeStates {
+		count, err := exportStates(filepath.Join(options.Dir, backupStatesDir))
+		if err != nil {
+			return err
+		}
+		manifest.States = &BackupStates{Dir: backupStatesDir, Count: count}
+		fmt.Printf("exported %d states to '%s'\n", count, backupStatesDir)
+	}
+
+	manifestData, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(options.Dir, BackupManifestFilename), manifestData, 0644)
+}
+
+func getAllResourceFields(kind backupKind) ([]map[string]interface{}, error) {
+	var responseData []byte
+	var err error
+	if kind.kind == "rule" {
+		responseData, err = api.GetOrderedRules()
+	} else {
+		responseData, err = api.GetResource(kind.kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var response map[string][]map[string]interface{}
+	err = json.Unmarshal(responseData, &response)
+	return response[kind.listKey], err
+}
+
+// exportStates writes the screenshot ('ID-DEVICE.png') and OCR text
+// ('ID-DEVICE.txt') of every state, these are kept for reference only since
+// states are reported by the agents and can't be restored.
+func exportStates(dir string) (int, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return 0, err
+	}
+	states, err := GetStates("", "", "", "")
+	if err != nil {
+		return 0, err
+	}
+	for _, state := range states {
+		baseFilename := filepath.Join(dir, strconv.Itoa(state.StateId)+"-"+state.DeviceUID)
+		var screenshot []byte
+		if state.Screenshot != "" {
+			screenshot, err = base64.StdEncoding.DecodeString(state.Screenshot)
+		} else {
+			screenshot, err = api.GetScreenshotByStateId(strconv.Itoa(state.StateId))
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the screenshot of state %d: %v", state.StateId, err)
+		}
+		err = ioutil.WriteFile(baseFilename+".png", screenshot, 0644)
+		if err != nil {
+			return 0, err
+		}
+		err = ioutil.WriteFile(baseFilename+".txt", []byte(state.OcrText), 0644)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(states), nil
+}
+
+// Import applies the files listed in the backup manifest in dependency order
+// and restores the order of the exported rules.
+func Import(dir string) error {
+	manifestData, err := ioutil.ReadFile(filepath.Join(dir, BackupManifestFilename))
+	if err != nil {
+		return fmt.Errorf("'%s' is not a backup directory: %v", dir, err)
+	}
+	var manifest BackupManifest
+	err = yaml.Unmarshal(manifestData, &manifest)
+	if err != nil {
+		return err
+	}
+	if manifest.Version != backupVersion {
+		return fmt.Errorf("backup version %d is not supported", manifest.Version)
+	}
+	var paths []string
+	for _, filename := range manifest.Files {
+		paths = append(paths, filepath.Join(dir, filename))
+	}
+	fmt.Printf("Importing backup of '%s' from %s\n", manifest.Server, manifest.ExportedAt)
+	total := 0
+	for _, count := range manifest.Counts {
+		total += count
+	}
+	if total == 0 {
+		fmt.Println("The backup is empty")
+		return nil
+	}
+	err = runManifests(ManifestSource{Paths: paths}, false, applyManifest)
+	if err != nil {
+		return err
+	}
+	manifests, err := ReadManifests(ManifestSource{Paths: paths})
+	if err != nil {
+		return err
+	}
+	var ruleNames []string
+	for _, manifest := range manifests {
+		if manifest.Kind == "rule" {
+			ruleNames = append(ruleNames, manifest.Name)
+		}
+	}
+	err = orderRules(ruleNames)
+	if err == nil && len(ruleNames) > 1 {
+		fmt.Println("rules reordered")
+	}
+	return err
+}
@@ -0,0 +1,350 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// benchMetadataKey tags every device created by a bench run, resources
+// without metadata are tagged by their name prefix instead.
+const (
+	benchMetadataKey = "vaxctl-bench"
+	benchScreenshot  = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+)
+
+type BenchOptions struct {
+	RunId       string
+	Devices     int
+	Creds       int
+	Rules       int
+	Workers     int
+	Rate        int
+	Duration    time.Duration
+	Cleanup     bool
+	CleanupOnly bool
+}
+
+type BenchResult struct {
+	Operation string `header:"Operation"`
+	Count     int    `header:"Count"`
+	Errors    int    `header:"Errors"`
+	ErrorRate string `header:"Error Rate"`
+	P50       string `header:"p50"`
+	P90       string `header:"p90"`
+	P99       string `header:"p99"`
+	Max       string `header:"Max"`
+}
+
+type benchStats struct {
+	lock      sync.Mutex
+	durations map[string][]time.Duration
+	errors    map[string]int
+	lastError map[string]error
+}
+
+func newBenchStats() *benchStats {
+	return &benchStats{durations: map[string][]time.Duration{}, errors: map[string]int{}, lastError: map[string]error{}}
+}
+
+func (s *benchStats) timed(operation string, call func() ([]byte, error)) error {
+	start := time.Now()
+	_, err := call()
+	elapsed := time.Since(start)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.durations[operation] = append(s.durations[operation], elapsed)
+	if err != nil {
+		s.errors[operation]++
+		s.lastError[operation] = err
+	}
+	return err
+}
+
+func (s *benchStats) results() []BenchResult {
+	var operations []string
+	for operation := range s.durations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	var results []BenchResult
+	for _, operation := range operations {
+		durations := s.durations[operation]
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		results = append(results, BenchResult{
+			Operation: operation,
+			Count:     len(durations),
+			Errors:    s.errors[operation],
+			ErrorRate: fmt.Sprintf("%.2f%%", float64(s.errors[operation])*100/float64(len(durations))),
+			P50:       percentile(durations, 50).String(),
+			P90:       percentile(durations, 90).String(),
+			P99:       percentile(durations, 99).String(),
+			Max:       durations[len(durations)-1].Round(time.Microsecond).String(),
+		})
+	}
+	return results
+}
+
+func percentile(sortedDurations []time.Duration, percent int) time.Duration {
+	idx := (len(sortedDurations)*percent+99)/100 - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sortedDurations[idx].Round(time.Microsecond)
+}
+
+func NewBenchRunId() string {
+	return strconv.FormatInt(time.Now().Unix(), 36)
+}
+
+func benchPrefix(runId string) string {
+	return fmt.Sprintf("bench-%s-", runId)
+}
+
+// RunBench sets up the resources, posts the states and cleans up. An
+// interrupt (SIGINT or SIGTERM) stops the setup or the load early and the
+// cleanup still runs, a second one exits right away.
+func RunBench(options BenchOptions) error {
+	stats := newBenchStats()
+	prefix := benchPrefix(options.RunId)
+	fmt.Printf("Bench run ID: %s\n", options.RunId)
+
+	var stop <-chan struct{}
+	if !options.CleanupOnly {
+		var done chan<- struct{}
+		stop, done = notifyBenchInterrupt(options)
+		err := benchSetup(options, prefix, stats, stop)
+		if err == nil {
+			benchLoad(options, prefix, stats, stop)
+		}
+		close(done)
+		if err != nil {
+			return err
+		}
+	}
+	if options.Cleanup || options.CleanupOnly {
+		err := benchCleanup(options, prefix, stats)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	helpers.PrintTable(stats.results())
+	for operation, err := range stats.lastError {
+		fmt.Printf("Last error for '%s': %s\n", operation, strings.ReplaceAll(err.Error(), "\n", " "))
+	}
+	if stop != nil && isStopped(stop) {
+		return fmt.Errorf("bench run '%s' was interrupted", options.RunId)
+	}
+	return nil
+}
+
+// notifyBenchInterrupt returns a channel closed on the first interrupt, the
+// default handling (exit) is restored for the next one. Closing done stops
+// the notifications.
+func notifyBenchInterrupt(options BenchOptions) (<-chan struct{}, chan<- struct{}) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			signal.Stop(signals)
+			return
+		}
+		signal.Stop(signals)
+		if options.Cleanup {
+			fmt.Println("Interrupted, cleaning up (interrupt again to exit now)")
+		} else {
+			fmt.Printf("Interrupted, run 'vaxctl bench --cleanup-only --run-id %s' to clean up\n", options.RunId)
+		}
+		close(stop)
+	}()
+	return stop, done
+}
+
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// runConcurrently calls the function for every index using a pool of workers.
+func runConcurrently(workers int, count int, call func(idx int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				call(idx)
+			}
+		}()
+	}
+	for idx := 0; idx < count; idx++ {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// benchSetup creates the resources of the run, the remaining ones are
+// skipped once stopped.
+func benchSetup(options BenchOptions, prefix string, stats *benchStats, stop <-chan struct{}) error {
+	start := time.Now()
+	actionName := prefix + "action"
+	actionData, _ := json.Marshal(Action{Name: actionName, Type: "sleep", Data: "1"})
+	err := stats.timed("POST action/", func() ([]byte, error) { return api.PostResourceFromBytes("action", actionData) })
+	if err != nil {
+		return fmt.Errorf("failed to create bench action: %v", err)
+	}
+
+	runConcurrently(options.Workers, options.Creds, func(idx int) {
+		if isStopped(stop) {
+			return
+		}
+		credData, _ := json.Marshal(Cred{Name: fmt.Sprintf("%scred-%d", prefix, idx), Username: "bench", Password: options.RunId})
+		stats.timed("POST creds/", func() ([]byte, error) { return api.PostResourceFromBytes("creds", credData) })
+	})
+
+	// rules are created sequentially since each one is placed relative to the others
+	for idx := 0; idx < options.Rules && !isStopped(stop); idx++ {
+		ruleData, _ := json.Marshal(Rule{
+			Name:       fmt.Sprintf("%srule-%d", prefix, idx),
+			Regex:      fmt.Sprintf("%srule-%d$", prefix, idx),
+			Actions:    []string{actionName},
+			Enabled:    true,
+			Screenshot: benchScreenshot,
+		})
+		stats.timed("POST rule/", func() ([]byte, error) { return api.PostResourceFromBytes("rule", ruleData) })
+	}
+
+	runConcurrently(options.Workers, options.Devices, func(idx int) {
+		if isStopped(stop) {
+			return
+		}
+		device := Device{
+			UID:      fmt.Sprintf("%sdevice-%d", prefix, idx),
+			IpmiIp:   fmt.Sprintf("10.%d.%d.%d", (idx>>16)&255, (idx>>8)&255, idx&255),
+			Model:    "bench",
+			Metadata: map[string]string{benchMetadataKey: options.RunId},
+		}
+		if options.Creds > 0 {
+			device.CredsName = fmt.Sprintf("%scred-%d", prefix, idx%options.Creds)
+		}
+		deviceData, _ := json.Marshal(device)
+		stats.timed("POST device/", func() ([]byte, error) { return api.PostResourceFromBytes("device", deviceData) })
+	})
+	fmt.Printf("Setup done in %v\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// benchLoad posts states for random devices at the requested rate (states
+// per second) until the duration passes, the OCR text matches a random rule
+// for servers that accept it.
+func benchLoad(options BenchOptions, prefix string, stats *benchStats, stop <-chan struct{}) {
+	if options.Devices == 0 || options.Duration == 0 || options.Rate == 0 || isStopped(stop) {
+		return
+	}
+	jobs := make(chan int, options.Workers)
+	var wg sync.WaitGroup
+	for worker := 0; worker < options.Workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				state := simulatedState{
+					DeviceUID:  fmt.Sprintf("%sdevice-%d", prefix, rand.Intn(options.Devices)),
+					Screenshot: benchScreenshot,
+					OcrText:    fmt.Sprintf("state %d", idx),
+				}
+				if options.Rules > 0 {
+					state.OcrText = fmt.Sprintf("%srule-%d", prefix, rand.Intn(options.Rules))
+				}
+				stateData, _ := json.Marshal(state)
+				stats.timed("PUT state/", func() ([]byte, error) { return api.PutResourceFromBytes("state", stateData) })
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(options.Rate))
+	sent := 0
+	for time.Since(start) < options.Duration && !isStopped(stop) {
+		select {
+		case <-ticker.C:
+			jobs <- sent
+			sent++
+		case <-stop:
+		}
+	}
+	ticker.Stop()
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+	fmt.Printf("Load done: %d states in %v (%.1f/s)\n", sent, elapsed.Round(time.Millisecond), float64(sent)/elapsed.Seconds())
+}
+
+// benchCleanup deletes everything created by the run, found by the device
+// metadata tag and the name prefix of the other resources.
+func benchCleanup(options BenchOptions, prefix string, stats *benchStats) error {
+	start := time.Now()
+	devices, err := GetDevices("")
+	if err != nil {
+		return err
+	}
+	var deviceUIDs []string
+	for _, device := range devices {
+		if device.Metadata[benchMetadataKey] == options.RunId {
+			deviceUIDs = append(deviceUIDs, device.UID)
+		}
+	}
+	runConcurrently(options.Workers, len(deviceUIDs), func(idx int) {
+		stats.timed("DELETE device/", func() ([]byte, error) { return api.DeleteResource("device", deviceUIDs[idx]) })
+	})
+
+	for _, resource := range []string{"rule", "action", "creds"} {
+		var names []string
+		switch resource {
+		case "rule":
+			names, err = GetRuleNames()
+		case "action":
+			names, err = GetActionNames()
+		case "creds":
+			names, err = GetCredNames()
+		}
+		if err != nil {
+			return err
+		}
+		var prefixedNames []string
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				prefixedNames = append(prefixedNames, name)
+			}
+		}
+		runConcurrently(options.Workers, len(prefixedNames), func(idx int) {
+			stats.timed("DELETE "+resource+"/", func() ([]byte, error) { return api.DeleteResource(resource, prefixedNames[idx]) })
+		})
+	}
+	fmt.Printf("Cleanup done in %v\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
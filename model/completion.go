@@ -49,3 +49,11 @@ func GetWorkIdsForCompletion(cmd *cobra.Command, args []string, toComplete strin
 	}
 	return names, cobra.ShellCompDirectiveNoFileComp
 }
+
+func GetMacroNamesForCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := getMacroNames()
+	if err != nil {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
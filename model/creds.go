@@ -89,7 +89,11 @@ func SetCredsAsDefault(name string) error {
 }
 
 func GenerateCred(filename string, mandatoryFlag bool, commentsFlag bool) error {
-	props := []helpers.PropInfo{
+	return GenerateResource(credProps(), filename, mandatoryFlag, commentsFlag)
+}
+
+func credProps() []helpers.PropInfo {
+	return []helpers.PropInfo{
 		{
 			Name:      "name",
 			Type:      "string",
@@ -109,5 +113,4 @@ func GenerateCred(filename string, mandatoryFlag bool, commentsFlag bool) error
 			Mandatory: true,
 		},
 	}
-	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
 }
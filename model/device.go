@@ -80,12 +80,24 @@ func GetDeviceNames() ([]string, error) {
 }
 
 func GenerateDevice(filename string, mandatoryFlag bool, commentsFlag bool) error {
-	allCreds, err := GetCredNames()
+	props, err := deviceProps(true)
 	if err != nil {
 		return err
 	}
+	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
+}
+
+// deviceProps returns the props of a device, the cred names are only listed
+// when online.
+func deviceProps(online bool) ([]helpers.PropInfo, error) {
 	credNameConstraint := make(map[string]interface{})
-	credNameConstraint["enum"] = append([]string{"default"}, allCreds...)
+	if online {
+		allCreds, err := GetCredNames()
+		if err != nil {
+			return nil, err
+		}
+		credNameConstraint["enum"] = append([]string{"default"}, allCreds...)
+	}
 
 	props := []helpers.PropInfo{
 		{
@@ -128,5 +140,5 @@ func GenerateDevice(filename string, mandatoryFlag bool, commentsFlag bool) erro
 			Mandatory:    false,
 		},
 	}
-	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
+	return props, nil
 }
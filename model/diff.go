@@ -0,0 +1,187 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// serverManagedFields are set by the server and ignored when comparing
+// resources, 'position' is only compared when it's set in the file and the
+// write-only fields are instructions that aren't returned by the server.
+var (
+	serverManagedFields = []string{"last_updated", "created_at", "agent_version", "heartbeat_timestamp"}
+	writeOnlyFields     = []string{"after_rule", "before_rule", "state_id"}
+	diffableKinds       = []string{"creds", "device", "action", "rule", "macro"}
+)
+
+// GetResourceFields fetches a resource from the server as a generic map,
+// nil is returned when it doesn't exist.
+func GetResourceFields(kind string, name string) (map[string]interface{}, error) {
+	var responseData []byte
+	var err error
+	if kind == "macro" {
+		macros, err := readMacroStore()
+		return macros[name], err
+	}
+	if kind == "device" {
+		responseData, err = api.GetResourceByUID(kind, name)
+	} else {
+		responseData, err = api.GetResourceByName(kind, name)
+	}
+	if err != nil {
+		if errVal, ok := err.(*api.HttpError); ok && errVal.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var response map[string][]map[string]interface{}
+	err = json.Unmarshal(responseData, &response)
+	if err != nil {
+		return nil, err
+	}
+	for _, resources := range response {
+		if len(resources) > 0 {
+			return resources[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// NormalizeResource removes the server managed and write-only fields, the
+// position is only kept when it's set in the reference (the local document).
+func NormalizeResource(fields map[string]interface{}, reference map[string]interface{}) map[string]interface{} {
+	normalized := map[string]interface{}{}
+	for key, value := range fields {
+		if helpers.StringInSlice(key, serverManagedFields) || helpers.StringInSlice(key, writeOnlyFields) {
+			continue
+		}
+		if _, ok := reference[key]; !ok && key == "position" {
+			continue
+		}
+		normalized[key] = value
+	}
+	return normalized
+}
+
+// mergeFields returns the live fields updated by the local ones, which is
+// the resource the server holds after applying the local document.
+func mergeFields(live map[string]interface{}, local map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for key, value := range live {
+		merged[key] = value
+	}
+	for key, value := range local {
+		merged[key] = value
+	}
+	return merged
+}
+
+// maskPasswords hides the passwords of creds in the diffs, changed passwords
+// are still shown as changed.
+func maskPasswords(source map[string]interface{}, target map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	maskedSource, maskedTarget := mergeFields(source, nil), mergeFields(target, nil)
+	if _, ok := source["password"]; ok {
+		maskedSource["password"] = "<redacted>"
+	}
+	if _, ok := target["password"]; ok {
+		maskedTarget["password"] = "<redacted>"
+		if sourcePassword, ok := source["password"]; ok && sourcePassword != target["password"] {
+			maskedTarget["password"] = "<redacted, changed>"
+		}
+	}
+	return maskedSource, maskedTarget
+}
+
+// DiffResource returns the unified diff between the live and the local
+// resource as YAML, it's empty when applying wouldn't change anything. The
+// passwords of creds are masked, a changed one is only reported as changed.
+// The sync tag of devices is kept by apply so it's not reported as removed.
+func DiffResource(kind string, name string, source string, local map[string]interface{}, live map[string]interface{}) ([]string, error) {
+	merged := mergeFields(live, local)
+	if kind == "device" {
+		keepSyncTag(live, merged)
+	}
+	if kind == "creds" {
+		maskedLive, maskedMerged := maskPasswords(live, merged)
+		if live != nil {
+			live = maskedLive
+		}
+		merged = maskedMerged
+	}
+	liveText := ""
+	liveName := "/dev/null"
+	if live != nil {
+		liveYaml, err := helpers.EncodeToYaml(NormalizeResource(live, local))
+		if err != nil {
+			return nil, err
+		}
+		liveText = string(liveYaml)
+		liveName = fmt.Sprintf("live/%s/%s", kind, name)
+	}
+	localYaml, err := helpers.EncodeToYaml(NormalizeResource(merged, local))
+	if err != nil {
+		return nil, err
+	}
+	return helpers.UnifiedDiff(liveName, fmt.Sprintf("%s/%s/%s", source, kind, name), liveText, string(localYaml)), nil
+}
+
+// DiffManifests prints the diff of every document against the live server,
+// true is returned when any of the resources differ.
+func DiffManifests(source ManifestSource) (bool, error) {
+	manifests, err := ReadManifests(source)
+	if err != nil {
+		return false, err
+	}
+	manifests, err = SortManifests(manifests)
+	if err != nil {
+		return false, err
+	}
+	differences := false
+	failed := 0
+	for _, manifest := range manifests {
+		lines, err := diffManifest(manifest)
+		if err != nil {
+			failed++
+			fmt.Printf("%s failed (%s): %s\n", manifest, manifest.location(), strings.ReplaceAll(err.Error(), "\n", " "))
+			continue
+		}
+		if len(lines) > 0 {
+			differences = true
+			helpers.PrintDiff(lines)
+		}
+	}
+	if failed > 0 {
+		return differences, fmt.Errorf("%d of %d documents failed", failed, len(manifests))
+	}
+	return differences, nil
+}
+
+func diffManifest(manifest Manifest) ([]string, error) {
+	if manifest.Err != nil {
+		return nil, manifest.Err
+	}
+	if manifest.Name == "" {
+		return nil, fmt.Errorf("'%s' is missing", manifestNameField(manifest.Kind))
+	}
+	if !helpers.StringInSlice(manifest.Kind, diffableKinds) {
+		return nil, fmt.Errorf("%s resources can't be compared", manifest.Kind)
+	}
+	var local map[string]interface{}
+	err := json.Unmarshal(manifest.Data, &local)
+	if err != nil {
+		return nil, err
+	}
+	live, err := GetResourceFields(manifest.Kind, manifest.Name)
+	if err != nil {
+		return nil, err
+	}
+	source := manifest.Source
+	if source == helpers.StdinFilename {
+		source = "stdin"
+	}
+	return DiffResource(manifest.Kind, manifest.Name, source, local, live)
+}
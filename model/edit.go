@@ -0,0 +1,236 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+const editHeader = `# Please edit the resources below, lines starting with '#' are ignored and an
+# empty file aborts the edit. Resources removed from the file are not changed.
+#
+`
+
+// editHiddenFields are too large to edit as text, they are sent unchanged
+// when they are not set in the edited file (and a rule doesn't set a
+// state_id instead).
+var editHiddenFields = []string{"screenshot", "ocr_text"}
+
+// EditResources opens the resources as YAML documents in $EDITOR, an invalid
+// file is re-opened with the errors as comments and only the changed
+// resources are sent.
+func EditResources(kind string, names []string) error {
+	original := map[string]map[string]interface{}{}
+	var documents []string
+	for _, name := range names {
+		live, err := GetResourceFields(kind, name)
+		if err != nil {
+			return err
+		}
+		if live == nil {
+			return fmt.Errorf("%s '%s' was not found", kind, name)
+		}
+		live = NormalizeResource(live, nil)
+		original[name] = live
+		fields := map[string]interface{}{"kind": kind}
+		for key, value := range live {
+			if !helpers.StringInSlice(key, editHiddenFields) {
+				fields[key] = value
+			}
+		}
+		data, err := helpers.EncodeToYaml(fields)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, string(data))
+	}
+
+	file, err := ioutil.TempFile("", "vaxctl-edit-*.yaml")
+	if err != nil {
+		return err
+	}
+	filename := file.Name()
+	file.Close()
+	content := strings.Join(documents, "---\n")
+	lastContent := content
+	header := editHeader
+	for {
+		err = ioutil.WriteFile(filename, []byte(header+content), 0600)
+		if err != nil {
+			return err
+		}
+		err = runEditor(filename)
+		if err != nil {
+			return fmt.Errorf("%v, your changes are kept in '%s'", err, filename)
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		content = stripEditHeader(string(data))
+		if isEmptyEdit(content) {
+			os.Remove(filename)
+			fmt.Println("Edit cancelled, no changes made.")
+			return nil
+		}
+		if content == lastContent {
+			if header == editHeader {
+				os.Remove(filename)
+				fmt.Println("Edit cancelled, no changes made.")
+				return nil
+			}
+			return fmt.Errorf("edit cancelled, no valid changes were saved, your changes are kept in '%s'", filename)
+		}
+		lastContent = content
+		manifests, errs := readEditedManifests(kind, filename, content, original)
+		if len(errs) > 0 {
+			header = editHeader + "# The edited file has errors:\n" + commentLines(strings.Join(errs, "\n")) + "#\n"
+			continue
+		}
+		failed := 0
+		for _, manifest := range manifests {
+			result, err := saveEditedManifest(manifest, original[manifest.Name])
+			if err != nil {
+				failed++
+				fmt.Printf("%s failed: %s\n", manifest, strings.ReplaceAll(err.Error(), "\n", " "))
+			} else if api.IsDryRun() {
+				fmt.Printf("%s %s (dry run)\n", manifest, result)
+			} else {
+				fmt.Printf("%s %s\n", manifest, result)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d resources failed, your changes are kept in '%s'", failed, len(manifests), filename)
+		}
+		os.Remove(filename)
+		return nil
+	}
+}
+
+// runEditor opens the file in $EDITOR (with its arguments, e.g. 'code
+// --wait'), vi when it's not set.
+func runEditor(filename string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	command := exec.Command(editor[0], append(editor[1:], filename)...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	err := command.Run()
+	if err != nil {
+		return fmt.Errorf("editor '%s' failed: %v", strings.Join(editor, " "), err)
+	}
+	return nil
+}
+
+// stripEditHeader removes the comment lines at the top of the file, the
+// header and the errors of the previous attempt.
+func stripEditHeader(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	for idx, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			return strings.Join(lines[idx:], "")
+		}
+	}
+	return ""
+}
+
+func isEmptyEdit(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "---" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+func commentLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, strings.TrimRight("# "+line, " ")+"\n")
+	}
+	return strings.Join(lines, "")
+}
+
+// readEditedManifests parses and validates the edited documents, the kind
+// and the names can't be changed.
+func readEditedManifests(kind string, filename string, content string, original map[string]map[string]interface{}) ([]Manifest, []string) {
+	var manifests []Manifest
+	var errs []string
+	seen := map[string]bool{}
+	for _, document := range helpers.SplitDocuments(filename, []byte(content)) {
+		manifest := parseManifest(document)
+		location := fmt.Sprintf("document %d", document.Index+1)
+		if manifest.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", location, manifest.Err))
+			continue
+		}
+		if manifest.Kind != kind {
+			errs = append(errs, fmt.Sprintf("%s: kind can't be changed from '%s'", location, kind))
+			continue
+		}
+		if _, ok := original[manifest.Name]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: '%s' can't be changed (%s '%s' is not edited)", location, manifestNameField(kind), kind, manifest.Name))
+			continue
+		}
+		if seen[manifest.Name] {
+			errs = append(errs, fmt.Sprintf("%s: %s is set twice", location, manifest))
+			continue
+		}
+		seen[manifest.Name] = true
+		var fields map[string]interface{}
+		json.Unmarshal(manifest.Data, &fields)
+		for _, field := range editHiddenFields {
+			if fields["state_id"] != nil {
+				break
+			}
+			if _, ok := fields[field]; !ok && original[manifest.Name][field] != nil {
+				fields[field] = original[manifest.Name][field]
+			}
+		}
+		err := validateResourceFields(kind, fields)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s is invalid:\n%v", location, manifest, err))
+			continue
+		}
+		manifest.Data, _ = json.Marshal(fields)
+		manifests = append(manifests, manifest)
+	}
+	return manifests, errs
+}
+
+// saveEditedManifest sends the edited resource when it differs from the
+// original one.
+func saveEditedManifest(manifest Manifest, original map[string]interface{}) (string, error) {
+	var fields map[string]interface{}
+	json.Unmarshal(manifest.Data, &fields)
+	if reflect.DeepEqual(fields, original) {
+		return "unchanged", nil
+	}
+	expansions, err := expandRuleFields(manifest.Kind, fields)
+	if err != nil {
+		return "", err
+	}
+	manifest.Data, err = json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	if manifest.Kind == "macro" {
+		_, err = saveMacro(manifest)
+	} else {
+		_, err = api.PutResourceFromBytes(manifest.Kind, manifest.Data)
+	}
+	if err == nil && len(expansions) > 0 {
+		err = recordMacroExpansions(manifest.Name, expansions)
+	}
+	return "edited", err
+}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// exportedFields drops the fields set by the server that are not covered by
+// NormalizeResource, so the exported resources can be applied as is.
+var exportedFields = map[string][]string{
+	"state": {"state_id", "matched_rule"},
+}
+
+type exportedResource struct {
+	filename string
+	fields   map[string]interface{}
+}
+
+// ExportResources prints the resources (a slice of one of the model types)
+// as re-appliable documents with a 'kind' field, when the output dir is set
+// each one is written to its own file instead, named by the file IDs (or by
+// the resource names when not set).
+func ExportResources(kind string, resources interface{}, fileIds []string, output string, outputDir string) error {
+	jsonData, err := json.Marshal(resources)
+	if err != nil {
+		return err
+	}
+	var resourcesFields []map[string]interface{}
+	err = json.Unmarshal(jsonData, &resourcesFields)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = "yaml"
+	}
+	if output != "yaml" && output != "json" {
+		return fmt.Errorf("output format '%s' is not valid for export (allowed values are: json & yaml)", output)
+	}
+
+	var exported []exportedResource
+	for idx, fields := range resourcesFields {
+		fileId := fmt.Sprint(fields[manifestNameField(kind)])
+		if idx < len(fileIds) {
+			fileId = fileIds[idx]
+		}
+		normalized := NormalizeResource(fields, nil)
+		for _, field := range exportedFields[kind] {
+			delete(normalized, field)
+		}
+		normalized["kind"] = kind
+		exported = append(exported, exportedResource{
+			filename: fmt.Sprintf("%s-%s.%s", kind, strings.ReplaceAll(fileId, string(os.PathSeparator), "_"), output),
+			fields:   normalized,
+		})
+	}
+
+	if outputDir != "" {
+		err = os.MkdirAll(outputDir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	var documents []string
+	for _, resource := range exported {
+		var data []byte
+		if output == "json" {
+			data, err = json.MarshalIndent(resource.fields, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data, err = helpers.EncodeToYaml(resource.fields)
+		}
+		if err != nil {
+			return err
+		}
+		if outputDir == "" {
+			documents = append(documents, string(data))
+			continue
+		}
+		fileMode := os.FileMode(0644)
+		if kind == "creds" {
+			fileMode = 0600
+		}
+		path := filepath.Join(outputDir, resource.filename)
+		err = ioutil.WriteFile(path, data, fileMode)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("wrote '%s'\n", path)
+	}
+	if outputDir == "" {
+		fmt.Print(strings.Join(documents, "---\n"))
+	}
+	return nil
+}
+
+// ExportRules always keeps the screenshots, the server only returns those
+// and a rule can't be applied without one (or a state_id). The OCR texts are
+// only kept when verbose.
+func ExportRules(name string, verbose bool, output string, outputDir string) error {
+	rules, err := GetRules(name)
+	if err != nil {
+		return err
+	}
+	if !verbose {
+		for idx := range rules {
+			rules[idx].OcrText = ""
+		}
+	}
+	return ExportResources("rule", rules, nil, output, outputDir)
+}
+
+func ExportActions(name string, output string, outputDir string) error {
+	actions, err := GetActions(name)
+	if err != nil {
+		return err
+	}
+	return ExportResources("action", actions, nil, output, outputDir)
+}
+
+func ExportCreds(name string, output string, outputDir string) error {
+	creds, err := GetCreds(name)
+	if err != nil {
+		return err
+	}
+	return ExportResources("creds", creds, nil, output, outputDir)
+}
+
+func ExportDevices(uid string, output string, outputDir string) error {
+	devices, err := GetDevices(uid)
+	if err != nil {
+		return err
+	}
+	return ExportResources("device", devices, nil, output, outputDir)
+}
+
+func ExportStates(id string, stateType string, deviceUid string, regex string, output string, outputDir string) error {
+	states, err := GetStates(id, stateType, deviceUid, regex)
+	if err != nil {
+		return err
+	}
+	var fileIds []string
+	for _, state := range states {
+		fileIds = append(fileIds, fmt.Sprintf("%d-%s", state.StateId, state.DeviceUID))
+	}
+	return ExportResources("state", states, fileIds, output, outputDir)
+}
+
+// ExportWorks exports works as assignments of their actions, applying them
+// assigns new work.
+func ExportWorks(workId string, deviceUID string, latest bool, output string, outputDir string) error {
+	var responseData []byte
+	var err error
+	if workId != "" {
+		responseData, err = api.GetWorkByID(workId)
+	} else if deviceUID != "" {
+		responseData, err = api.GetWorksByDevice(deviceUID)
+	} else {
+		responseData, err = api.GetResource("work")
+	}
+	if err != nil {
+		return err
+	}
+	var responseObject WorksResponse
+	json.Unmarshal(responseData, &responseObject)
+	works := responseObject.Works
+	if latest && len(works) > 0 {
+		works = works[len(works)-1:]
+	}
+	var assignments []WorkAssignment
+	var fileIds []string
+	for _, work := range works {
+		assignments = append(assignments, workAssignment(work))
+		fileIds = append(fileIds, strconv.Itoa(work.Id))
+	}
+	return ExportResources("work", assignments, fileIds, output, outputDir)
+}
+
+func workAssignment(work Work) WorkAssignment {
+	assignment := WorkAssignment{DeviceUID: work.DeviceUID}
+	for _, action := range work.Actions {
+		assignment.Actions = append(assignment.Actions, action.Name)
+	}
+	return assignment
+}
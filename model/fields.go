@@ -0,0 +1,298 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// CreateResourceFromFields creates a resource from the fields set with flags,
+// they are validated against the values known by the server before sending.
+func CreateResourceFromFields(kind string, name string, fields map[string]interface{}) error {
+	fields, expansions, err := resourceFields(kind, name, nil, fields)
+	if err != nil {
+		return err
+	}
+	return postNewResource(kind, name, fields, expansions)
+}
+
+// CopyResource creates a resource from the fields of an existing one (a rule
+// keeps its screenshot and OCR text) changed by the fields set with flags, a
+// copied device must have its own IPMI IP.
+func CopyResource(kind string, source string, name string, fields map[string]interface{}) error {
+	live, err := GetResourceFields(kind, source)
+	if err != nil {
+		return err
+	}
+	if live == nil {
+		return fmt.Errorf("%s '%s' was not found", kind, source)
+	}
+	base := NormalizeResource(live, nil)
+	delete(base, "is_default")
+	fields, expansions, err := resourceFields(kind, name, base, fields)
+	if err != nil {
+		return err
+	}
+	if kind == "device" && fields["ipmi_ip"] == base["ipmi_ip"] {
+		return fmt.Errorf("device '%s' must have its own IPMI IP, set it with '--ipmi-ip'", name)
+	}
+	return postNewResource(kind, name, fields, expansions)
+}
+
+// CopyName returns the first unused name for a copy of the resource
+// ('NAME-copy', 'NAME-copy-2', ...).
+func CopyName(kind string, name string) (string, error) {
+	names, err := getResourceNames(kind)
+	if err != nil {
+		return "", err
+	}
+	copyName := name + "-copy"
+	for idx := 2; helpers.StringInSlice(copyName, names); idx++ {
+		copyName = fmt.Sprintf("%s-copy-%d", name, idx)
+	}
+	return copyName, nil
+}
+
+// postNewResource creates the resource, the macro references of a rule are
+// recorded once it's created.
+func postNewResource(kind string, name string, fields map[string]interface{}, expansions []macroExpansion) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	manifest := Manifest{Kind: kind, Name: name, Data: data}
+	exists, err := manifestExists(manifest)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s already exists", manifest)
+	}
+	_, err = api.PostResourceFromBytes(kind, data)
+	if err != nil {
+		return err
+	}
+	printResourceResult(manifest, "created")
+	return recordMacroExpansions(name, expansions)
+}
+
+// SetResourceFields changes the fields set with flags of an existing
+// resource, the metadata keys are merged with the existing ones.
+func SetResourceFields(kind string, name string, fields map[string]interface{}) error {
+	live, err := GetResourceFields(kind, name)
+	if err != nil {
+		return err
+	}
+	if live == nil {
+		return fmt.Errorf("%s '%s' was not found", kind, name)
+	}
+	fields, expansions, err := resourceFields(kind, name, NormalizeResource(live, nil), fields)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = api.PutResourceFromBytes(kind, data)
+	if err != nil {
+		return err
+	}
+	printResourceResult(Manifest{Kind: kind, Name: name, Data: data}, "configured")
+	if len(expansions) == 0 {
+		// the actions may be the live ones, their record is kept
+		return nil
+	}
+	return recordMacroExpansions(name, expansions)
+}
+
+func printResourceResult(manifest Manifest, result string) {
+	if api.IsDryRun() {
+		fmt.Printf("%s %s (dry run)\n", manifest, result)
+	} else {
+		fmt.Printf("%s %s\n", manifest, result)
+	}
+}
+
+// resourceFields merges the flag fields into the base ones and validates the
+// result, the screenshot flag is a filename that is sent base64 encoded and
+// the macros of rule actions are expanded once the fields are valid (their
+// expansions are returned to be recorded once the rule is sent).
+func resourceFields(kind string, name string, base map[string]interface{}, flagFields map[string]interface{}) (map[string]interface{}, []macroExpansion, error) {
+	data, err := json.Marshal(flagFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	var changed map[string]interface{}
+	json.Unmarshal(data, &changed)
+	if screenshotFile, ok := changed["screenshot"].(string); ok {
+		screenshot, err := ioutil.ReadFile(screenshotFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		changed["screenshot"] = base64.StdEncoding.EncodeToString(screenshot)
+	}
+
+	fields := map[string]interface{}{}
+	for key, value := range base {
+		fields[key] = value
+	}
+	if _, ok := changed["state_id"]; ok {
+		delete(fields, "screenshot")
+		delete(fields, "ocr_text")
+	}
+	for key, value := range changed {
+		if metadata, ok := value.(map[string]interface{}); ok && key == "metadata" {
+			merged, _ := fields[key].(map[string]interface{})
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			for metaKey, metaValue := range metadata {
+				merged[metaKey] = metaValue
+			}
+			value = merged
+		}
+		fields[key] = value
+	}
+	fields[manifestNameField(kind)] = name
+
+	err = validateResourceFields(kind, fields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is invalid:\n%v", Manifest{Kind: kind, Name: name}, err)
+	}
+	expansions, err := expandRuleFields(kind, fields)
+	return fields, expansions, err
+}
+
+// expandRuleFields expands the macros of the actions of a rule and creates
+// the generated actions, the expansions are recorded by the callers once the
+// rule is sent.
+func expandRuleFields(kind string, fields map[string]interface{}) ([]macroExpansion, error) {
+	actionNames, ok := fieldActionNames(fields)
+	if !ok || kind != "rule" {
+		return nil, nil
+	}
+	actionNames, expansions, err := expandActionNames(actionNames)
+	if err != nil {
+		return nil, err
+	}
+	fields["actions"] = actionNames
+	return expansions, nil
+}
+
+// fieldActionNames returns the names of the 'actions' field, false when it's
+// not set or not a list of names.
+func fieldActionNames(fields map[string]interface{}) ([]string, bool) {
+	actions, ok := fields["actions"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	actionNames := []string{}
+	for _, action := range actions {
+		actionName, ok := action.(string)
+		if !ok {
+			return nil, false
+		}
+		actionNames = append(actionNames, actionName)
+	}
+	return actionNames, true
+}
+
+// validateResourceFields checks the fields against the schema of the kind
+// and the values known by the server: action types, power options, special
+// keys and the names of the referenced resources. The actions of rules are
+// checked apart since they may reference macros.
+func validateResourceFields(kind string, fields map[string]interface{}) error {
+	props, err := resourceProps(kind, kind != "rule")
+	if err != nil {
+		return err
+	}
+	errs := helpers.ValidateProps(props, fields)
+	switch kind {
+	case "action":
+		actionErrs, err := validateActionData(fields)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, actionErrs...)
+	case "rule":
+		ruleErrs, err := validateRuleReferences(fields)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, ruleErrs...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, fmt.Sprintf("  - %s", strings.ReplaceAll(err.Error(), "\n", " ")))
+	}
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
+
+// validateActionData checks the data of an action by type: power actions
+// take one of the power options and keystroke actions the special keys of
+// the server.
+func validateActionData(fields map[string]interface{}) ([]error, error) {
+	actionType, _ := fields["action_type"].(string)
+	data, _ := fields["action_data"].(string)
+	var errs []error
+	switch actionType {
+	case "power":
+		powerOptions, err := GetPowerOptions()
+		if err != nil {
+			return nil, err
+		}
+		if !helpers.StringInSlice(data, powerOptions) {
+			errs = append(errs, fmt.Errorf("'action_data' must be one of: %s", strings.Join(powerOptions, ", ")))
+		}
+	case "keystroke":
+		specialKeys, err := GetSpecialKeys()
+		if err != nil {
+			return nil, err
+		}
+		for _, err := range helpers.ValidateKeystrokes(data, specialKeys) {
+			errs = append(errs, fmt.Errorf("'action_data' %v", err))
+		}
+	}
+	return errs, nil
+}
+
+// validateRuleReferences checks that the actions (macro references are
+// expanded later) and the neighbour rules of a rule exist.
+func validateRuleReferences(fields map[string]interface{}) ([]error, error) {
+	var references manifestReferences
+	data, _ := json.Marshal(fields)
+	json.Unmarshal(data, &references)
+	var errs []error
+	if len(references.Actions) > 0 {
+		actionNames, err := GetActionNames()
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range references.Actions {
+			if !strings.HasPrefix(action, MacroPrefix) && !helpers.StringInSlice(action, actionNames) {
+				errs = append(errs, fmt.Errorf("'actions' action '%s' was not found", action))
+			}
+		}
+	}
+	if references.AfterRule != "" || references.BeforeRule != "" {
+		ruleNames, err := GetRuleNames()
+		if err != nil {
+			return nil, err
+		}
+		if references.AfterRule != "" && !helpers.StringInSlice(references.AfterRule, ruleNames) {
+			errs = append(errs, fmt.Errorf("'after_rule' rule '%s' was not found", references.AfterRule))
+		}
+		if references.BeforeRule != "" && !helpers.StringInSlice(references.BeforeRule, ruleNames) {
+			errs = append(errs, fmt.Errorf("'before_rule' rule '%s' was not found", references.BeforeRule))
+		}
+	}
+	return errs, nil
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"vaxctl/api"
@@ -8,13 +9,77 @@ import (
 )
 
 func ApplyResource(resource string, filename string) error {
-	_, err := api.UpdateResourceFromFile(resource, filename)
-	return err
+	if resource == "device" {
+		data, err := helpers.ReadFileToJSON(filename)
+		if err != nil {
+			return err
+		}
+		var device api.UIDRequest
+		json.Unmarshal(data, &device)
+		data, err = keepLiveSyncTag(device.UID, data)
+		if err != nil {
+			return err
+		}
+		_, err = api.UpdateResourceFromBytes(resource, device.UID, data)
+		return err
+	}
+	if resource != "rule" {
+		_, err := api.UpdateResourceFromFile(resource, filename)
+		return err
+	}
+	manifest, err := readExpandedFile(resource, filename)
+	if err != nil {
+		return err
+	}
+	_, err = api.UpdateResourceFromBytes(resource, manifest.Name, manifest.Data)
+	if err != nil {
+		return err
+	}
+	return recordMacroExpansions(manifest.Name, manifest.expansions)
 }
 
 func CreateResource(resource string, filename string) error {
-	_, err := api.PostResourceFromFile(resource, filename)
-	return err
+	if resource != "rule" {
+		_, err := api.PostResourceFromFile(resource, filename)
+		return err
+	}
+	manifest, err := readExpandedFile(resource, filename)
+	if err != nil {
+		return err
+	}
+	_, err = api.PostResourceFromBytes(resource, manifest.Data)
+	if err != nil {
+		return err
+	}
+	return recordMacroExpansions(manifest.Name, manifest.expansions)
+}
+
+// readExpandedFile reads a rule or work file as JSON with the macro
+// references of its actions expanded, the expansions of a rule are recorded
+// by the callers once it's sent.
+func readExpandedFile(kind string, filename string) (Manifest, error) {
+	manifest := Manifest{Source: filename, Kind: kind}
+	data, err := helpers.ReadFileToJSON(filename)
+	if err != nil {
+		return manifest, err
+	}
+	manifest.Data = data
+	var fields map[string]interface{}
+	if json.Unmarshal(data, &fields) != nil {
+		// the server reports the invalid document
+		return manifest, nil
+	}
+	manifest.Name, _ = fields["name"].(string)
+	if kind == "rule" {
+		manifest.expansions, err = expandRuleFields(kind, fields)
+	} else if actionNames, ok := fieldActionNames(fields); ok {
+		fields["actions"], _, err = expandActionNames(actionNames)
+	}
+	if err != nil {
+		return manifest, err
+	}
+	manifest.Data, err = json.Marshal(fields)
+	return manifest, err
 }
 
 func DeleteResource(resource string, filename string, name string) error {
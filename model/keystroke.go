@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"vaxctl/helpers"
+)
+
+// ValidateKeystrokeActions checks the data of keystroke actions against the
+// special keys of the server: the given data, the named action or all of
+// them. The canonical form is printed when it differs from the data.
+func ValidateKeystrokeActions(name string, data string) error {
+	specialKeys, err := GetSpecialKeys()
+	if err != nil {
+		return err
+	}
+	var actions []Action
+	if data != "" {
+		actions = []Action{{Name: "<data>", Type: "keystroke", Data: data}}
+	} else {
+		actions, err = GetActions(name)
+		if err != nil {
+			return err
+		}
+	}
+	checked := 0
+	failed := 0
+	for _, action := range actions {
+		if action.Type != "keystroke" {
+			if name != "" {
+				return fmt.Errorf("action '%s' is of type '%s', only keystroke actions can be validated", action.Name, action.Type)
+			}
+			continue
+		}
+		checked++
+		combos, syntaxErr := helpers.ParseKeystrokes(action.Data)
+		formatted := helpers.FormatKeystrokes(combos, specialKeys)
+		errs := helpers.ValidateKeystrokes(action.Data, specialKeys)
+		if len(errs) > 0 {
+			failed++
+			fmt.Printf("action/%s invalid:\n", action.Name)
+			for _, err := range errs {
+				fmt.Printf("  - %v\n", err)
+			}
+			if syntaxErr == nil && len(helpers.ValidateKeystrokes(formatted, specialKeys)) == 0 {
+				fmt.Printf("  fixed form: %s\n", formatted)
+			}
+			continue
+		}
+		if formatted != action.Data {
+			fmt.Printf("action/%s valid, canonical form: %s\n", action.Name, formatted)
+		} else {
+			fmt.Printf("action/%s valid\n", action.Name)
+		}
+	}
+	if checked == 0 {
+		fmt.Println("No keystroke actions found")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d keystroke actions are invalid", failed, checked)
+	}
+	return nil
+}
+
+// validateKeystrokeManifest checks the syntax of a keystroke action offline,
+// the special keys are only known to the server.
+func validateKeystrokeManifest(fields map[string]interface{}) []error {
+	actionType, _ := fields["action_type"].(string)
+	data, ok := fields["action_data"].(string)
+	if actionType != "keystroke" || !ok {
+		return nil
+	}
+	_, err := helpers.ParseKeystrokes(data)
+	if err != nil {
+		return []error{fmt.Errorf("'action_data' %s", strings.ReplaceAll(err.Error(), "\n", " "))}
+	}
+	return nil
+}
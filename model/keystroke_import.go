@@ -0,0 +1,355 @@
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"vaxctl/helpers"
+)
+
+type ImportKeystrokesOptions struct {
+	Asciinema string
+	Script    string
+	Name      string
+	Pause     time.Duration
+	Filename  string
+}
+
+// importStep is either keys sent to the console or a pause in seconds.
+type importStep struct {
+	combos []helpers.KeystrokeCombo
+	sleep  float64
+}
+
+type asciinemaHeader struct {
+	Version int `json:"version"`
+}
+
+// ImportKeystrokes converts the input of a recorded session (asciinema) or
+// a script (expect/xdotool) to keystroke actions, with sleep actions for the
+// pauses, and writes them as documents ready to apply.
+func ImportKeystrokes(options ImportKeystrokesOptions) error {
+	var steps []importStep
+	var err error
+	if options.Asciinema != "" {
+		steps, err = readAsciinemaSteps(options.Asciinema, options.Pause)
+	} else {
+		steps, err = readScriptSteps(options.Script)
+	}
+	if err != nil {
+		return err
+	}
+	actions, err := importedActions(options.Name, steps)
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		return fmt.Errorf("no keys were found")
+	}
+
+	var names []string
+	var documents []string
+	for _, action := range actions {
+		names = append(names, action.Name)
+		data, err := helpers.EncodeToYaml(map[string]string{
+			"kind":        "action",
+			"name":        action.Name,
+			"action_type": action.Type,
+			"action_data": action.Data,
+		})
+		if err != nil {
+			return err
+		}
+		documents = append(documents, string(data))
+	}
+	content := fmt.Sprintf("# rule actions: [%s]\n", strings.Join(names, ", ")) + strings.Join(documents, "---\n")
+	if options.Filename == "" {
+		fmt.Print(content)
+		return nil
+	}
+	err = ioutil.WriteFile(options.Filename, []byte(content), 0644)
+	if err == nil {
+		fmt.Printf("wrote %d actions to '%s'\n", len(actions), options.Filename)
+	}
+	return err
+}
+
+// importedActions joins the keys between pauses into keystroke actions
+// (typed text that follows typed text is joined as well), the name is used
+// as is when there is a single action.
+func importedActions(name string, steps []importStep) ([]Action, error) {
+	var actions []Action
+	var combos []helpers.KeystrokeCombo
+	keystrokeCount, sleepCount := 0, 0
+	flush := func() {
+		if len(combos) == 0 {
+			return
+		}
+		keystrokeCount++
+		actions = append(actions, Action{
+			Name: fmt.Sprintf("%s-%d", name, keystrokeCount),
+			Type: "keystroke",
+			Data: helpers.FormatKeystrokes(combos, nil),
+		})
+		combos = nil
+	}
+	for _, step := range steps {
+		if step.sleep > 0 {
+			flush()
+			if len(actions) == 0 {
+				continue
+			}
+			sleepCount++
+			actions = append(actions, Action{
+				Name: fmt.Sprintf("%s-sleep-%d", name, sleepCount),
+				Type: "sleep",
+				Data: strconv.Itoa(int(math.Max(1, math.Round(step.sleep)))),
+			})
+			continue
+		}
+		for _, combo := range step.combos {
+			last := len(combos) - 1
+			if last >= 0 && !combo.IsCombination() && !combos[last].IsCombination() {
+				combos[last].Text += combo.Text
+			} else {
+				combos = append(combos, combo)
+			}
+		}
+	}
+	flush()
+	if len(actions) > 0 && actions[len(actions)-1].Type == "sleep" {
+		actions = actions[:len(actions)-1]
+	}
+	if keystrokeCount == 1 && sleepCount == 0 {
+		actions[0].Name = name
+	}
+	for _, action := range actions {
+		if action.Type != "keystroke" {
+			continue
+		}
+		if _, err := helpers.ParseKeystrokes(action.Data); err != nil {
+			return nil, fmt.Errorf("action '%s' can't be expressed as keystrokes ('%s'): %v", action.Name, action.Data, err)
+		}
+	}
+	return actions, nil
+}
+
+// readAsciinemaSteps reads the input events of an asciinema v2 (absolute
+// times) or v3 (intervals) recording, a pause is inserted when the time
+// between two events is at least the given one.
+func readAsciinemaSteps(filename string, pause time.Duration) ([]importStep, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("'%s' is empty", filename)
+	}
+	var header asciinemaHeader
+	err = json.Unmarshal(scanner.Bytes(), &header)
+	if err != nil || (header.Version != 2 && header.Version != 3) {
+		return nil, fmt.Errorf("'%s' is not an asciinema v2 or v3 recording", filename)
+	}
+
+	var steps []importStep
+	lineNumber := 1
+	eventTime, lastInputTime := 0.0, -1.0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var event []interface{}
+		err = json.Unmarshal([]byte(line), &event)
+		if err != nil || len(event) < 3 {
+			return nil, fmt.Errorf("%s:%d: invalid event", filename, lineNumber)
+		}
+		timestamp, _ := event[0].(float64)
+		if header.Version == 3 {
+			eventTime += timestamp
+		} else {
+			eventTime = timestamp
+		}
+		eventType, _ := event[1].(string)
+		data, _ := event[2].(string)
+		if eventType != "i" {
+			continue
+		}
+		if lastInputTime >= 0 && eventTime-lastInputTime >= pause.Seconds() {
+			steps = append(steps, importStep{sleep: eventTime - lastInputTime})
+		}
+		lastInputTime = eventTime
+		combos, err := helpers.DecodeTerminalInput(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+		}
+		steps = append(steps, importStep{combos: combos})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if lastInputTime < 0 {
+		return nil, fmt.Errorf("'%s' has no input events (record with 'asciinema rec --stdin')", filename)
+	}
+	return steps, nil
+}
+
+// xdotoolOptionsWithValue are skipped with their values.
+var xdotoolOptionsWithValue = []string{"--delay", "--window", "--repeat", "--repeat-delay"}
+
+// ignoredScriptCommands control expect and don't send keys.
+var ignoredScriptCommands = []string{"spawn", "expect", "set", "interact", "exit", "log_user", "close", "wait"}
+
+// readScriptSteps reads an expect ('send', 'sleep') or xdotool ('type',
+// 'key', 'sleep', with or without the 'xdotool' prefix) script.
+func readScriptSteps(filename string) ([]importStep, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var steps []importStep
+	for idx, line := range strings.Split(string(data), "\n") {
+		words, err := splitScriptLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, idx+1, err)
+		}
+		if len(words) > 0 && words[0] == "xdotool" {
+			words = words[1:]
+		}
+		if len(words) == 0 || strings.HasPrefix(words[0], "#") || helpers.StringInSlice(words[0], ignoredScriptCommands) {
+			continue
+		}
+		command := words[0]
+		// long options are only taken before the first argument (or up to '--')
+		var args []string
+		options := true
+		for argIdx := 1; argIdx < len(words); argIdx++ {
+			switch {
+			case options && words[argIdx] == "--":
+				options = false
+			case options && helpers.StringInSlice(words[argIdx], xdotoolOptionsWithValue):
+				argIdx++
+			case options && strings.HasPrefix(words[argIdx], "--"):
+			default:
+				options = false
+				args = append(args, words[argIdx])
+			}
+		}
+		var step importStep
+		switch command {
+		case "send", "type":
+			step.combos, err = helpers.DecodeTerminalInput(strings.Join(args, " "))
+		case "key":
+			step.combos, err = helpers.DecodeXdotoolKeys(args)
+		case "sleep":
+			if len(args) != 1 {
+				err = fmt.Errorf("'sleep' takes a number of seconds")
+				break
+			}
+			step.sleep, err = strconv.ParseFloat(args[0], 64)
+		default:
+			err = fmt.Errorf("unknown command '%s'", command)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, idx+1, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// splitScriptLine splits a line into words, double quoted words support the
+// escapes of expect (e.g. '\r', '\033', '\x1b'), single quoted words are
+// taken as is.
+func splitScriptLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	runes := []rune(strings.TrimSpace(line))
+	for idx := 0; idx < len(runes); idx++ {
+		char := runes[idx]
+		switch {
+		case char == ' ' || char == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case char == '\'':
+			inWord = true
+			for idx++; idx < len(runes) && runes[idx] != '\''; idx++ {
+				word.WriteRune(runes[idx])
+			}
+			if idx == len(runes) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+		case char == '"':
+			inWord = true
+			idx++
+			for ; idx < len(runes) && runes[idx] != '"'; idx++ {
+				if runes[idx] != '\\' || idx+1 == len(runes) {
+					word.WriteRune(runes[idx])
+					continue
+				}
+				idx++
+				escaped, length := scriptEscape(runes[idx:])
+				word.WriteString(escaped)
+				idx += length - 1
+			}
+			if idx == len(runes) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+		default:
+			inWord = true
+			word.WriteRune(char)
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// scriptEscape decodes the escape after a backslash, returning the value and
+// the number of runes it used.
+func scriptEscape(runes []rune) (string, int) {
+	switch runes[0] {
+	case 'r':
+		return "\r", 1
+	case 'n':
+		return "\n", 1
+	case 't':
+		return "\t", 1
+	case 'e':
+		return "\x1b", 1
+	case 'b':
+		return "\b", 1
+	case 'x':
+		end := 1
+		for end < len(runes) && end < 3 && strings.ContainsRune("0123456789abcdefABCDEF", runes[end]) {
+			end++
+		}
+		if value, err := strconv.ParseUint(string(runes[1:end]), 16, 8); err == nil {
+			return string(rune(value)), end
+		}
+	case '0', '1', '2', '3':
+		end := 1
+		for end < len(runes) && end < 3 && strings.ContainsRune("01234567", runes[end]) {
+			end++
+		}
+		if value, err := strconv.ParseUint(string(runes[:end]), 8, 8); err == nil {
+			return string(rune(value)), end
+		}
+	}
+	return string(runes[0]), 1
+}
@@ -0,0 +1,599 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/viper"
+)
+
+const (
+	// MacroPrefix marks the references to macros in the actions of rules and
+	// works, e.g. 'macro:enter-setup(key=F10,wait=5)'.
+	MacroPrefix = "macro:"
+	// generatedActionPrefix names the actions generated for the inline
+	// actions of macros ('macro-MACRO_NAME-HASH').
+	generatedActionPrefix = "macro-"
+	defaultMacrosFilename = ".vaxctl-macros.yaml"
+)
+
+var (
+	macroReferenceRegex = regexp.MustCompile(`^macro:([^()\s]+)(?:\((.*)\))?$`)
+	macroParamRegex     = regexp.MustCompile(`\$\{([A-Za-z0-9_-]+)\}`)
+)
+
+// Macro is a client-side named list of actions, expanded into plain action
+// names when the rules and works referencing it are applied. The params are
+// used as '${NAME}' in the items, a param without a default must be set by
+// the references.
+type Macro struct {
+	Name    string                 `json:"name"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	Actions []MacroItem            `json:"actions"`
+}
+
+// MacroItem is the name of an action (or a macro reference), an inline
+// action or a nested macro with its params.
+type MacroItem struct {
+	Action     string                 `json:"action,omitempty"`
+	ActionType string                 `json:"action_type,omitempty"`
+	ActionData string                 `json:"action_data,omitempty"`
+	Macro      string                 `json:"macro,omitempty"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+}
+
+type macroStore struct {
+	Macros []map[string]interface{} `json:"macros"`
+	// Expansions are the macro references of the applied rules by rule name.
+	Expansions map[string][]macroExpansion `json:"expansions,omitempty" yaml:"expansions,omitempty"`
+}
+
+// macroExpansion is a macro reference of the actions of a rule with the
+// actions it was expanded to, the first one at index Start.
+type macroExpansion struct {
+	Reference string   `json:"reference"`
+	Start     int      `json:"start"`
+	Actions   []string `json:"actions"`
+}
+
+// ExpandedAction is an action of a rule with the macro reference it was
+// expanded from.
+type ExpandedAction struct {
+	Rule     string `json:"rule" yaml:"rule" header:"Rule"`
+	Position int    `json:"position" yaml:"position" header:"#"`
+	Action   string `json:"action" yaml:"action" header:"Action"`
+	Type     string `json:"action_type" yaml:"action_type" header:"Type"`
+	Data     string `json:"action_data" yaml:"action_data" header:"Data"`
+	Macro    string `json:"macro,omitempty" yaml:"macro,omitempty" header:"Macro"`
+}
+
+func (i *MacroItem) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &i.Action)
+	}
+	type macroItem MacroItem
+	return json.Unmarshal(data, (*macroItem)(i))
+}
+
+func macrosFilename() string {
+	if filename := viper.GetString("macros"); filename != "" {
+		return filename
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return defaultMacrosFilename
+	}
+	return filepath.Join(home, defaultMacrosFilename)
+}
+
+// readStoreFile reads the store file, set with 'macros' in the config file.
+func readStoreFile() (macroStore, error) {
+	var store macroStore
+	data, err := ioutil.ReadFile(macrosFilename())
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+	err = yaml.Unmarshal(data, &store)
+	if err != nil {
+		return store, fmt.Errorf("failed to read the macros from '%s': %v", macrosFilename(), err)
+	}
+	return store, nil
+}
+
+func writeStoreFile(store macroStore) error {
+	if store.Macros == nil {
+		store.Macros = []map[string]interface{}{}
+	}
+	data, err := helpers.EncodeToYaml(store)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(macrosFilename(), data, 0644)
+}
+
+// readMacroStore returns the stored macros by name.
+func readMacroStore() (map[string]map[string]interface{}, error) {
+	store, err := readStoreFile()
+	if err != nil {
+		return nil, err
+	}
+	macros := map[string]map[string]interface{}{}
+	for _, fields := range store.Macros {
+		name, _ := fields["name"].(string)
+		macros[name] = fields
+	}
+	return macros, nil
+}
+
+// writeMacroStore replaces the stored macros, the recorded expansions are
+// kept.
+func writeMacroStore(macros map[string]map[string]interface{}) error {
+	store, err := readStoreFile()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	store.Macros = []map[string]interface{}{}
+	for _, name := range names {
+		store.Macros = append(store.Macros, macros[name])
+	}
+	return writeStoreFile(store)
+}
+
+// recordMacroExpansions replaces the macro references recorded for a rule
+// (unless in dry run), they tell 'get rule --expanded' which actions come
+// from which macro.
+func recordMacroExpansions(rule string, expansions []macroExpansion) error {
+	if rule == "" || api.IsDryRun() {
+		return nil
+	}
+	store, err := readStoreFile()
+	if err != nil {
+		if len(expansions) == 0 {
+			return nil
+		}
+		return err
+	}
+	if _, ok := store.Expansions[rule]; !ok && len(expansions) == 0 {
+		return nil
+	}
+	if store.Expansions == nil {
+		store.Expansions = map[string][]macroExpansion{}
+	}
+	if len(expansions) == 0 {
+		delete(store.Expansions, rule)
+	} else {
+		store.Expansions[rule] = expansions
+	}
+	return writeStoreFile(store)
+}
+
+// renameMacroExpansions rewrites the rule (kind rule) or the action names
+// (kind action) of the recorded expansions.
+func renameMacroExpansions(kind string, oldName string, newName string) error {
+	store, err := readStoreFile()
+	if err != nil || len(store.Expansions) == 0 || api.IsDryRun() {
+		return err
+	}
+	switch kind {
+	case "rule":
+		expansions, ok := store.Expansions[oldName]
+		if !ok {
+			return nil
+		}
+		delete(store.Expansions, oldName)
+		store.Expansions[newName] = expansions
+	case "action":
+		for _, expansions := range store.Expansions {
+			for _, expansion := range expansions {
+				for idx, action := range expansion.Actions {
+					if action == oldName {
+						expansion.Actions[idx] = newName
+					}
+				}
+			}
+		}
+	default:
+		return nil
+	}
+	return writeStoreFile(store)
+}
+
+// saveMacro stores the macro (unless in dry run), true is returned when it
+// already existed.
+func saveMacro(manifest Manifest) (bool, error) {
+	var fields map[string]interface{}
+	err := json.Unmarshal(manifest.Data, &fields)
+	if err != nil {
+		return false, err
+	}
+	var macro Macro
+	err = json.Unmarshal(manifest.Data, &macro)
+	if err != nil {
+		return false, fmt.Errorf("invalid macro: %v", err)
+	}
+	macros, err := readMacroStore()
+	if err != nil {
+		return false, err
+	}
+	_, exists := macros[manifest.Name]
+	if api.IsDryRun() {
+		return exists, nil
+	}
+	macros[manifest.Name] = fields
+	return exists, writeMacroStore(macros)
+}
+
+func deleteMacro(name string) error {
+	macros, err := readMacroStore()
+	if err != nil {
+		return err
+	}
+	if _, ok := macros[name]; !ok {
+		return fmt.Errorf("macro '%s' not found", name)
+	}
+	if api.IsDryRun() {
+		return nil
+	}
+	delete(macros, name)
+	return writeMacroStore(macros)
+}
+
+func getMacroNames() ([]string, error) {
+	macros, err := readMacroStore()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// JoinMacroReferences joins the references split by a comma separated flag
+// (e.g. 'macro:name(a=1' and 'b=2)').
+func JoinMacroReferences(actions []string) []string {
+	var joined []string
+	open := false
+	for _, action := range actions {
+		if open {
+			joined[len(joined)-1] += "," + action
+		} else {
+			joined = append(joined, action)
+		}
+		current := joined[len(joined)-1]
+		open = strings.HasPrefix(current, MacroPrefix) && strings.Count(current, "(") > strings.Count(current, ")")
+	}
+	return joined
+}
+
+// macroExpander expands macro references into action names, collecting the
+// actions generated for the inline actions.
+type macroExpander struct {
+	macros    map[string]map[string]interface{}
+	generated []Action
+}
+
+func newMacroExpander() (*macroExpander, error) {
+	macros, err := readMacroStore()
+	if err != nil {
+		return nil, err
+	}
+	return &macroExpander{macros: macros}, nil
+}
+
+func hasMacroReferences(actions []string) bool {
+	for _, action := range actions {
+		if strings.HasPrefix(action, MacroPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandActions returns the action names with the expansions of the macro
+// references.
+func (e *macroExpander) expandActions(actions []string) ([]string, []macroExpansion, error) {
+	var expanded []string
+	var expansions []macroExpansion
+	for _, action := range actions {
+		names, err := e.expandAction(action, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.HasPrefix(action, MacroPrefix) {
+			expansions = append(expansions, macroExpansion{Reference: action, Start: len(expanded), Actions: names})
+		}
+		expanded = append(expanded, names...)
+	}
+	return expanded, expansions, nil
+}
+
+func (e *macroExpander) expandAction(action string, stack []string) ([]string, error) {
+	if !strings.HasPrefix(action, MacroPrefix) {
+		return []string{action}, nil
+	}
+	match := macroReferenceRegex.FindStringSubmatch(action)
+	if match == nil {
+		return nil, fmt.Errorf("invalid macro reference '%s' (expected macro:NAME or macro:NAME(PARAM=VALUE,...))", action)
+	}
+	args := map[string]interface{}{}
+	if strings.TrimSpace(match[2]) != "" {
+		for _, arg := range strings.Split(match[2], ",") {
+			keyValue := strings.SplitN(arg, "=", 2)
+			if len(keyValue) != 2 || strings.TrimSpace(keyValue[0]) == "" {
+				return nil, fmt.Errorf("invalid param '%s' in macro reference '%s' (expected PARAM=VALUE)", arg, action)
+			}
+			args[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+		}
+	}
+	return e.expandMacro(match[1], args, stack)
+}
+
+func (e *macroExpander) expandMacro(name string, args map[string]interface{}, stack []string) ([]string, error) {
+	if helpers.StringInSlice(name, stack) {
+		return nil, fmt.Errorf("macro cycle detected: %s -> %s", strings.Join(stack, " -> "), name)
+	}
+	stack = append(stack, name)
+	fields, ok := e.macros[name]
+	if !ok {
+		return nil, fmt.Errorf("macro '%s' not found", name)
+	}
+	var macro Macro
+	data, _ := json.Marshal(fields)
+	err := json.Unmarshal(data, &macro)
+	if err != nil {
+		return nil, fmt.Errorf("invalid macro '%s': %v", name, err)
+	}
+
+	values := map[string]string{}
+	for param, value := range args {
+		if _, ok := macro.Params[param]; !ok {
+			return nil, fmt.Errorf("macro '%s' has no param '%s'", name, param)
+		}
+		values[param] = fmt.Sprint(value)
+	}
+	for param, value := range macro.Params {
+		if _, ok := values[param]; ok {
+			continue
+		}
+		if value == nil {
+			return nil, fmt.Errorf("param '%s' of macro '%s' must be set", param, name)
+		}
+		values[param] = fmt.Sprint(value)
+	}
+	substitute := func(text string) (string, error) {
+		var err error
+		substituted := macroParamRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+			param := macroParamRegex.FindStringSubmatch(placeholder)[1]
+			value, ok := values[param]
+			if !ok && err == nil {
+				err = fmt.Errorf("macro '%s' has no param '%s'", name, param)
+			}
+			return value
+		})
+		return substituted, err
+	}
+
+	var expanded []string
+	for _, item := range macro.Actions {
+		var names []string
+		switch {
+		case item.Macro != "":
+			itemArgs := map[string]interface{}{}
+			for param, value := range item.Params {
+				itemArgs[param], err = substitute(fmt.Sprint(value))
+				if err != nil {
+					return nil, err
+				}
+			}
+			names, err = e.expandMacro(item.Macro, itemArgs, stack)
+		case item.ActionType != "":
+			var action Action
+			action.Type, err = substitute(item.ActionType)
+			if err == nil {
+				action.Data, err = substitute(item.ActionData)
+			}
+			if err == nil {
+				names = []string{e.generateAction(name, action)}
+			}
+		case item.Action != "":
+			var action string
+			action, err = substitute(item.Action)
+			if err == nil {
+				names, err = e.expandAction(action, stack)
+			}
+		default:
+			err = fmt.Errorf("the items of macro '%s' must set 'action', 'action_type' or 'macro'", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, names...)
+	}
+	return expanded, nil
+}
+
+// generateAction names an inline action by its content, so the same action
+// is shared by all references.
+func (e *macroExpander) generateAction(macroName string, action Action) string {
+	hash := sha256.Sum256([]byte(action.Type + "\n" + action.Data))
+	action.Name = generatedActionPrefix + macroName + "-" + hex.EncodeToString(hash[:])[:8]
+	for _, generated := range e.generated {
+		if generated.Name == action.Name {
+			return action.Name
+		}
+	}
+	e.generated = append(e.generated, action)
+	return action.Name
+}
+
+// expandManifestMacros replaces the macro references of rules and works by
+// action names and adds the generated actions, the macros of the manifests
+// take precedence over the stored ones.
+func expandManifestMacros(manifests []Manifest) ([]Manifest, error) {
+	var expander *macroExpander
+	definedActions := map[string]bool{}
+	for _, manifest := range manifests {
+		if manifest.Err == nil && manifest.Kind == "action" {
+			definedActions[manifest.Name] = true
+		}
+	}
+	var generated []Manifest
+	for idx, manifest := range manifests {
+		if manifest.Err != nil || (manifest.Kind != "rule" && manifest.Kind != "work") {
+			continue
+		}
+		var references manifestReferences
+		json.Unmarshal(manifest.Data, &references)
+		if !hasMacroReferences(references.Actions) {
+			continue
+		}
+		if expander == nil {
+			var err error
+			expander, err = newMacroExpander()
+			if err != nil {
+				return nil, err
+			}
+			for _, macroManifest := range manifests {
+				if macroManifest.Err == nil && macroManifest.Kind == "macro" {
+					var fields map[string]interface{}
+					json.Unmarshal(macroManifest.Data, &fields)
+					expander.macros[macroManifest.Name] = fields
+				}
+			}
+		}
+		generatedCount := len(expander.generated)
+		actions, expansions, err := expander.expandActions(references.Actions)
+		if err != nil {
+			manifests[idx].Err = err
+			continue
+		}
+		var fields map[string]interface{}
+		json.Unmarshal(manifest.Data, &fields)
+		fields["actions"] = actions
+		manifests[idx].Data, manifests[idx].Err = json.Marshal(fields)
+		manifests[idx].expansions = expansions
+		for _, action := range expander.generated[generatedCount:] {
+			if definedActions[action.Name] {
+				continue
+			}
+			data, _ := json.Marshal(map[string]string{"name": action.Name, "action_type": action.Type, "action_data": action.Data})
+			generated = append(generated, Manifest{Source: manifest.Source, Index: manifest.Index, Kind: "action", Name: action.Name, Data: data, Generated: true})
+		}
+	}
+	return append(manifests, generated...), nil
+}
+
+// expandActionNames expands the macro references of a list of actions and
+// creates the generated actions that don't exist yet.
+func expandActionNames(actions []string) ([]string, []macroExpansion, error) {
+	if !hasMacroReferences(actions) {
+		return actions, nil, nil
+	}
+	expander, err := newMacroExpander()
+	if err != nil {
+		return nil, nil, err
+	}
+	expanded, expansions, err := expander.expandActions(actions)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, action := range expander.generated {
+		data, _ := json.Marshal(map[string]string{"name": action.Name, "action_type": action.Type, "action_data": action.Data})
+		manifest := Manifest{Kind: "action", Name: action.Name, Data: data}
+		exists, err := manifestExists(manifest)
+		if err == nil && !exists {
+			_, err = api.PostResourceFromBytes("action", data)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create action '%s' of a macro: %v", action.Name, err)
+		}
+	}
+	return expanded, expansions, nil
+}
+
+// expandedActionMacros returns the macro references of the actions of a rule
+// by index, an expansion recorded for the rule is only used while the rule
+// still has its actions in place.
+func expandedActionMacros(rule Rule, expansions []macroExpansion) map[int]string {
+	macros := map[int]string{}
+	for _, expansion := range expansions {
+		end := expansion.Start + len(expansion.Actions)
+		if expansion.Start < 0 || end > len(rule.Actions) || !reflect.DeepEqual(rule.Actions[expansion.Start:end], expansion.Actions) {
+			continue
+		}
+		for idx := expansion.Start; idx < end; idx++ {
+			macros[idx] = strings.TrimPrefix(expansion.Reference, MacroPrefix)
+		}
+	}
+	return macros
+}
+
+// PrintExpandedRules prints the actions of the rules in order with their
+// type, data and the macro reference they were expanded from.
+func PrintExpandedRules(name string, output string) error {
+	rules, err := GetRules(name)
+	if err != nil {
+		return err
+	}
+	allActions, err := GetActions("")
+	if err != nil {
+		return err
+	}
+	actionsByName := map[string]Action{}
+	for _, action := range allActions {
+		actionsByName[action.Name] = action
+	}
+	store, err := readStoreFile()
+	if err != nil {
+		return err
+	}
+	expandedActions := []ExpandedAction{}
+	for _, rule := range rules {
+		macros := expandedActionMacros(rule, store.Expansions[rule.Name])
+		for idx, actionName := range rule.Actions {
+			action := actionsByName[actionName]
+			expandedActions = append(expandedActions, ExpandedAction{
+				Rule:     rule.Name,
+				Position: idx + 1,
+				Action:   actionName,
+				Type:     action.Type,
+				Data:     action.Data,
+				Macro:    macros[idx],
+			})
+		}
+	}
+	switch output {
+	case "json":
+		returnObject, _ := json.MarshalIndent(expandedActions, "", "  ")
+		fmt.Println(string(returnObject))
+	case "yaml":
+		returnObject, _ := helpers.EncodeToYaml(expandedActions)
+		fmt.Println(string(returnObject))
+	default:
+		helpers.PrintTable(expandedActions)
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// Manifest is a single resource document of a multi-document manifest, the
+// 'kind' field selects the resource and is removed from the sent data.
+// Generated manifests are the actions of the macros referenced by a document.
+type Manifest struct {
+	Source    string
+	Index     int
+	Kind      string
+	Name      string
+	Data      []byte
+	Err       error
+	Generated bool
+	// expansions are the macro references of the actions of a rule.
+	expansions []macroExpansion
+}
+
+var manifestKinds = map[string]string{
+	"cred":   "creds",
+	"creds":  "creds",
+	"device": "device",
+	"action": "action",
+	"rule":   "rule",
+	"state":  "state",
+	"work":   "work",
+	"macro":  "macro",
+}
+
+func (m Manifest) String() string {
+	if m.Kind == "" {
+		return "<unknown>"
+	}
+	if m.Name == "" {
+		return m.Kind + "/<unnamed>"
+	}
+	return fmt.Sprintf("%s/%s", m.Kind, m.Name)
+}
+
+func (m Manifest) location() string {
+	source := m.Source
+	if source == helpers.StdinFilename {
+		source = "stdin"
+	}
+	return fmt.Sprintf("%s, document %d", source, m.Index+1)
+}
+
+// ManifestSource selects the files, directories (plain or overlays) or stdin
+// to read manifests from and the values used to render them as templates.
+type ManifestSource struct {
+	Paths       []string
+	Recursive   bool
+	ValuesFiles []string
+	SetValues   []string
+}
+
+// ReadManifests reads the documents of the source and resolves their kinds,
+// documents that can't be parsed have Err set. Files are rendered as templates
+// only when values are set.
+func ReadManifests(source ManifestSource) ([]Manifest, error) {
+	var values map[string]interface{}
+	if len(source.ValuesFiles) > 0 || len(source.SetValues) > 0 {
+		var err error
+		values, err = helpers.ReadValues(source.ValuesFiles, source.SetValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var manifests []Manifest
+	for _, path := range source.Paths {
+		pathManifests, err := readManifestPath(path, source.Recursive, values)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, pathManifests...)
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no documents were found in %s", strings.Join(source.Paths, ", "))
+	}
+	return expandManifestMacros(manifests)
+}
+
+func readManifestPath(path string, recursive bool, values map[string]interface{}) ([]Manifest, error) {
+	if isOverlayDir(path) {
+		return readOverlay(path, values, nil)
+	}
+	var render func(source string, data []byte) ([]byte, error)
+	if values != nil {
+		render = func(source string, data []byte) ([]byte, error) {
+			return helpers.RenderTemplate(source, data, values)
+		}
+	}
+	documents, err := helpers.ReadDocuments([]string{path}, recursive, render)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []Manifest
+	for _, document := range documents {
+		manifests = append(manifests, parseManifest(document))
+	}
+	return manifests, nil
+}
+
+func parseManifest(document helpers.Document) Manifest {
+	manifest := Manifest{Source: document.Source, Index: document.Index, Err: document.Err}
+	if manifest.Err != nil {
+		return manifest
+	}
+	var fields map[string]interface{}
+	err := json.Unmarshal(document.Data, &fields)
+	if err != nil {
+		manifest.Err = fmt.Errorf("document is not an object")
+		return manifest
+	}
+	kindValue, _ := fields["kind"].(string)
+	kind, ok := manifestKinds[strings.ToLower(kindValue)]
+	if !ok {
+		manifest.Kind = kindValue
+		if kindValue == "" {
+			manifest.Err = fmt.Errorf("'kind' is missing (allowed values are: cred, device, action, rule, state, work & macro)")
+		} else {
+			manifest.Err = fmt.Errorf("kind '%s' is not valid (allowed values are: cred, device, action, rule, state, work & macro)", kindValue)
+		}
+		return manifest
+	}
+	delete(fields, "kind")
+	manifest.Kind = kind
+	manifest.Name, _ = fields[manifestNameField(kind)].(string)
+	manifest.Data, manifest.Err = json.Marshal(fields)
+	return manifest
+}
+
+func manifestNameField(kind string) string {
+	switch kind {
+	case "device":
+		return "uid"
+	case "state", "work":
+		return "device_uid"
+	default:
+		return "name"
+	}
+}
+
+func manifestExists(manifest Manifest) (bool, error) {
+	var err error
+	if manifest.Kind == "device" {
+		_, err = api.GetResourceByUID(manifest.Kind, manifest.Name)
+	} else {
+		_, err = api.GetResourceByName(manifest.Kind, manifest.Name)
+	}
+	if err == nil {
+		return true, nil
+	}
+	if errVal, ok := err.(*api.HttpError); ok && errVal.Status == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+func applyManifest(manifest Manifest) (string, error) {
+	switch manifest.Kind {
+	case "macro":
+		exists, err := saveMacro(manifest)
+		if exists {
+			return "configured", err
+		}
+		return "created", err
+	case "state":
+		_, err := api.PutResourceFromBytes(manifest.Kind, manifest.Data)
+		return "applied", err
+	case "work":
+		_, err := api.CreateWorkAssignment(manifest.Data)
+		return "assigned", err
+	}
+	exists, err := manifestExists(manifest)
+	if err != nil {
+		return "", err
+	}
+	result := "created"
+	if exists && manifest.Kind == "device" {
+		manifest.Data, err = keepLiveSyncTag(manifest.Name, manifest.Data)
+		if err != nil {
+			return "", err
+		}
+	}
+	if exists {
+		_, err = api.PutResourceFromBytes(manifest.Kind, manifest.Data)
+		result = "configured"
+	} else {
+		_, err = api.PostResourceFromBytes(manifest.Kind, manifest.Data)
+	}
+	if err == nil && manifest.Kind == "rule" {
+		err = recordMacroExpansions(manifest.Name, manifest.expansions)
+	}
+	return result, err
+}
+
+func createManifest(manifest Manifest) (string, error) {
+	switch manifest.Kind {
+	case "macro":
+		exists, err := saveMacro(manifest)
+		if exists {
+			return "", fmt.Errorf("macro '%s' already exists", manifest.Name)
+		}
+		return "created", err
+	case "state":
+		_, err := api.PutResourceFromBytes(manifest.Kind, manifest.Data)
+		return "created", err
+	case "work":
+		_, err := api.CreateWorkAssignment(manifest.Data)
+		return "assigned", err
+	}
+	_, err := api.PostResourceFromBytes(manifest.Kind, manifest.Data)
+	if err == nil && manifest.Kind == "rule" {
+		err = recordMacroExpansions(manifest.Name, manifest.expansions)
+	}
+	return "created", err
+}
+
+func deleteManifest(manifest Manifest) (string, error) {
+	if manifest.Kind == "state" || manifest.Kind == "work" {
+		return "", fmt.Errorf("%s resources can't be deleted", manifest.Kind)
+	}
+	if manifest.Kind == "macro" {
+		return "deleted", deleteMacro(manifest.Name)
+	}
+	_, err := api.DeleteResource(manifest.Kind, manifest.Name)
+	if err == nil && manifest.Kind == "rule" {
+		err = recordMacroExpansions(manifest.Name, nil)
+	}
+	return "deleted", err
+}
+
+func ApplyManifests(source ManifestSource) error {
+	return runManifests(source, false, applyManifest)
+}
+
+func CreateManifests(source ManifestSource) error {
+	return runManifests(source, false, createManifest)
+}
+
+// DeleteManifests deletes in the reverse dependency order so resources are
+// removed before the ones they reference.
+// The actions generated for macros are kept, other rules may use them.
+func DeleteManifests(source ManifestSource) error {
+	return runManifests(source, true, func(manifest Manifest) (string, error) {
+		if manifest.Generated {
+			return "kept (generated by a macro)", nil
+		}
+		return deleteManifest(manifest)
+	})
+}
+
+// runManifests calls the function for every document in dependency order and
+// reports each result, a failed document doesn't stop the following ones.
+func runManifests(source ManifestSource, reverse bool, call func(manifest Manifest) (string, error)) error {
+	manifests, err := ReadManifests(source)
+	if err != nil {
+		return err
+	}
+	manifests, err = SortManifests(manifests)
+	if err != nil {
+		return err
+	}
+	if reverse {
+		for left, right := 0, len(manifests)-1; left < right; left, right = left+1, right-1 {
+			manifests[left], manifests[right] = manifests[right], manifests[left]
+		}
+	}
+	failed := 0
+	for _, manifest := range manifests {
+		err = manifest.Err
+		if err == nil && manifest.Name == "" {
+			err = fmt.Errorf("'%s' is missing", manifestNameField(manifest.Kind))
+		}
+		result := ""
+		if err == nil {
+			result, err = call(manifest)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("%s failed (%s): %s\n", manifest, manifest.location(), strings.ReplaceAll(err.Error(), "\n", " "))
+		} else if api.IsDryRun() {
+			fmt.Printf("%s %s (dry run)\n", manifest, result)
+		} else {
+			fmt.Printf("%s %s\n", manifest, result)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d documents failed", failed, len(manifests))
+	}
+	return nil
+}
+
+type manifestReferences struct {
+	CredsName  string   `json:"creds_name"`
+	Actions    []string `json:"actions"`
+	AfterRule  string   `json:"after_rule"`
+	BeforeRule string   `json:"before_rule"`
+	DeviceUID  string   `json:"device_uid"`
+	Rule       string   `json:"rule"`
+}
+
+// dependencies returns the keys ('kind/name') of the resources that must
+// exist before the manifest can be applied.
+func (m Manifest) dependencies() []string {
+	var references manifestReferences
+	json.Unmarshal(m.Data, &references)
+	var keys []string
+	addKey := func(kind string, name string) {
+		if name != "" {
+			keys = append(keys, kind+"/"+name)
+		}
+	}
+	switch m.Kind {
+	case "device":
+		addKey("creds", references.CredsName)
+	case "rule":
+		for _, action := range references.Actions {
+			addKey("action", action)
+		}
+		addKey("rule", references.AfterRule)
+		addKey("rule", references.BeforeRule)
+	case "state":
+		addKey("device", references.DeviceUID)
+	case "work":
+		addKey("device", references.DeviceUID)
+		addKey("rule", references.Rule)
+		for _, action := range references.Actions {
+			addKey("action", action)
+		}
+	}
+	return keys
+}
+
+// SortManifests orders the manifests so every resource comes after the ones
+// it references in the same bundle (creds before devices, actions before
+// rules, rule anchors before the rules placed relative to them), otherwise
+// the file order is kept. Documents that failed to parse keep their place.
+func SortManifests(manifests []Manifest) ([]Manifest, error) {
+	indexesByKey := map[string][]int{}
+	for idx, manifest := range manifests {
+		if manifest.Err == nil {
+			key := manifest.String()
+			indexesByKey[key] = append(indexesByKey[key], idx)
+		}
+	}
+
+	dependents := make([][]int, len(manifests))
+	dependencies := make([][]int, len(manifests))
+	pending := make([]int, len(manifests))
+	for idx, manifest := range manifests {
+		if manifest.Err != nil {
+			continue
+		}
+		for _, key := range manifest.dependencies() {
+			for _, dependencyIdx := range indexesByKey[key] {
+				if dependencyIdx != idx {
+					dependents[dependencyIdx] = append(dependents[dependencyIdx], idx)
+					dependencies[idx] = append(dependencies[idx], dependencyIdx)
+					pending[idx]++
+				}
+			}
+		}
+	}
+
+	var sorted []Manifest
+	done := make([]bool, len(manifests))
+	for len(sorted) < len(manifests) {
+		next := -1
+		for idx := range manifests {
+			if !done[idx] && pending[idx] == 0 {
+				next = idx
+				break
+			}
+		}
+		if next == -1 {
+			return nil, fmt.Errorf("dependency cycle detected: %s", strings.Join(manifestCycle(manifests, dependencies, done), " -> "))
+		}
+		done[next] = true
+		sorted = append(sorted, manifests[next])
+		for _, dependentIdx := range dependents[next] {
+			pending[dependentIdx]--
+		}
+	}
+	return sorted, nil
+}
+
+// manifestCycle follows the unresolved dependencies from the first blocked
+// manifest until one repeats, the repeated part is the cycle.
+func manifestCycle(manifests []Manifest, dependencies [][]int, done []bool) []string {
+	current := 0
+	for done[current] {
+		current++
+	}
+	visitedAt := map[int]int{}
+	var path []int
+	for {
+		if start, ok := visitedAt[current]; ok {
+			var keys []string
+			for _, idx := range append(path[start:], current) {
+				keys = append(keys, manifests[idx].String())
+			}
+			return keys
+		}
+		visitedAt[current] = len(path)
+		path = append(path, current)
+		for _, dependencyIdx := range dependencies[current] {
+			if !done[dependencyIdx] {
+				current = dependencyIdx
+				break
+			}
+		}
+	}
+}
+
+// RenderManifests prints the documents after rendering the templates and
+// resolving the overlays, as they would be sent (with a 'kind' field).
+func RenderManifests(source ManifestSource, output string) error {
+	manifests, err := ReadManifests(source)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	var documents []string
+	for _, manifest := range manifests {
+		if manifest.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s failed (%s): %s\n", manifest, manifest.location(), strings.ReplaceAll(manifest.Err.Error(), "\n", " "))
+			continue
+		}
+		var fields map[string]interface{}
+		json.Unmarshal(manifest.Data, &fields)
+		fields["kind"] = manifest.Kind
+		var data []byte
+		if output == "json" {
+			data, err = json.MarshalIndent(fields, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data, err = helpers.EncodeToYaml(fields)
+		}
+		if err != nil {
+			return err
+		}
+		documents = append(documents, string(data))
+	}
+	fmt.Print(strings.Join(documents, "---\n"))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d documents failed", failed, len(manifests))
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package model_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"vaxctl/fakeserver"
+	"vaxctl/model"
+
+	"github.com/spf13/viper"
+)
+
+var manifestFixtures = fakeserver.Fixtures{
+	Creds:   []model.Cred{{Name: "admin", Username: "root", Password: "calvin123"}},
+	Actions: []model.Action{{Name: "press-f1", Type: "keystroke", Data: "Keys.F1"}},
+	Rules: []fakeserver.FixtureRule{
+		{Name: "f1-prompt", Regex: "press f1", Actions: []string{"press-f1"}, Screenshot: "iVBORw0KGgo="},
+	},
+}
+
+func TestManifests(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(source model.ManifestSource) error
+		manifest    string
+		wantErr     string
+		wantActions []string
+		wantRules   []string
+		wantCreds   []string
+		wantDevices []string
+	}{
+		{
+			name: "apply creates in dependency order",
+			run:  model.ApplyManifests,
+			manifest: `
+kind: rule
+name: reboot-prompt
+regex: reboot
+actions: [reboot]
+screenshot: iVBORw0KGgo=
+after_rule: f1-prompt
+---
+kind: action
+name: reboot
+action_type: power
+action_data: cycle
+---
+kind: device
+uid: dev2
+ipmi_ip: 10.0.0.2
+model: r640
+creds_name: ops
+---
+kind: cred
+name: ops
+username: ops
+password: secret99
+`,
+			wantActions: []string{"press-f1=Keys.F1", "reboot=cycle"},
+			wantRules:   []string{"f1-prompt", "reboot-prompt"},
+			wantCreds:   []string{"admin", "ops"},
+			wantDevices: []string{"dev2"},
+		},
+		{
+			name: "apply configures existing resources",
+			run:  model.ApplyManifests,
+			manifest: `
+kind: action
+name: press-f1
+action_type: keystroke
+action_data: Keys.F2
+`,
+			wantActions: []string{"press-f1=Keys.F2"},
+			wantRules:   []string{"f1-prompt"},
+			wantCreds:   []string{"admin"},
+		},
+		{
+			name: "rules are placed relative to their anchors",
+			run:  model.ApplyManifests,
+			manifest: `
+kind: rule
+name: second
+regex: second
+actions: [press-f1]
+screenshot: iVBORw0KGgo=
+after_rule: first
+---
+kind: rule
+name: first
+regex: first
+actions: [press-f1]
+screenshot: iVBORw0KGgo=
+before_rule: f1-prompt
+`,
+			wantActions: []string{"press-f1=Keys.F1"},
+			wantRules:   []string{"first", "second", "f1-prompt"},
+			wantCreds:   []string{"admin"},
+		},
+		{
+			name: "create fails for existing resources",
+			run:  model.CreateManifests,
+			manifest: `
+kind: action
+name: press-f1
+action_type: keystroke
+action_data: Keys.F2
+`,
+			wantErr:     "1 of 1 documents failed",
+			wantActions: []string{"press-f1=Keys.F1"},
+			wantRules:   []string{"f1-prompt"},
+			wantCreds:   []string{"admin"},
+		},
+		{
+			name: "delete removes dependents first",
+			run:  model.DeleteManifests,
+			manifest: `
+kind: action
+name: press-f1
+---
+kind: rule
+name: f1-prompt
+`,
+			wantCreds: []string{"admin"},
+		},
+		{
+			name: "rule ordering cycles are rejected",
+			run:  model.ApplyManifests,
+			manifest: `
+kind: rule
+name: first
+regex: first
+actions: [press-f1]
+screenshot: iVBORw0KGgo=
+after_rule: second
+---
+kind: rule
+name: second
+regex: second
+actions: [press-f1]
+screenshot: iVBORw0KGgo=
+after_rule: first
+`,
+			wantErr:     "dependency cycle detected: rule/first -> rule/second -> rule/first",
+			wantActions: []string{"press-f1=Keys.F1"},
+			wantRules:   []string{"f1-prompt"},
+			wantCreds:   []string{"admin"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, err := fakeserver.New(manifestFixtures)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+			viper.Set("url", server.URL)
+			defer viper.Reset()
+
+			filename := filepath.Join(t.TempDir(), "manifest.yaml")
+			err = ioutil.WriteFile(filename, []byte(test.manifest), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = test.run(model.ManifestSource{Paths: []string{filename}})
+			if test.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+
+			actions, err := model.GetActions("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actionValues []string
+			for _, action := range actions {
+				actionValues = append(actionValues, action.Name+"="+action.Data)
+			}
+			rules, err := model.GetRuleNames()
+			if err != nil {
+				t.Fatal(err)
+			}
+			creds, err := model.GetCredNames()
+			if err != nil {
+				t.Fatal(err)
+			}
+			devices, err := model.GetDeviceNames()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, check := range []struct {
+				kind string
+				got  []string
+				want []string
+			}{
+				{"actions", actionValues, test.wantActions},
+				{"rules", rules, test.wantRules},
+				{"creds", creds, test.wantCreds},
+				{"devices", devices, test.wantDevices},
+			} {
+				if len(check.got) != 0 || len(check.want) != 0 {
+					if !reflect.DeepEqual(check.got, check.want) {
+						t.Errorf("got %s %v, want %v", check.kind, check.got, check.want)
+					}
+				}
+			}
+		})
+	}
+}
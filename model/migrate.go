@@ -0,0 +1,396 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+type MigrateOptions struct {
+	From    string
+	To      string
+	Kinds   []string
+	Names   []string
+	CredMap map[string]string
+	Diff    bool
+	Yes     bool
+}
+
+type CompareResult struct {
+	Kind   string `header:"Kind"`
+	Name   string `header:"Name"`
+	Status string `header:"Status"`
+}
+
+// serverResources holds the normalized resources of a server by kind and
+// name, and the names of its rules in order.
+type serverResources struct {
+	resources map[string]map[string]map[string]interface{}
+	ruleOrder []string
+}
+
+const (
+	onlyInSource = "only in source"
+	onlyInTarget = "only in target"
+	differs      = "differs"
+)
+
+// parseKinds returns the kinds of the server resources, macros are client-side
+// so they can't be compared or migrated.
+func parseKinds(kinds []string) ([]string, error) {
+	var serverKinds []string
+	for _, kind := range backupKinds {
+		serverKinds = append(serverKinds, kind.kind)
+	}
+	if len(kinds) == 0 {
+		return serverKinds, nil
+	}
+	var parsedKinds []string
+	for _, kind := range kinds {
+		parsedKind, ok := manifestKinds[strings.ToLower(kind)]
+		if !ok || !helpers.StringInSlice(parsedKind, serverKinds) {
+			return nil, fmt.Errorf("kind '%s' is not valid (allowed values are: cred, device, action & rule)", kind)
+		}
+		parsedKinds = append(parsedKinds, parsedKind)
+	}
+	return parsedKinds, nil
+}
+
+// migrateServers resolves the URLs of the source and the target contexts.
+func migrateServers(options MigrateOptions) (string, string, error) {
+	sourceUrl, err := api.ContextURL(options.From)
+	if err != nil {
+		return "", "", err
+	}
+	targetUrl, err := api.ContextURL(options.To)
+	return sourceUrl, targetUrl, err
+}
+
+// loadServerResources fetches the resources from the server, the context is
+// the name used in the errors.
+func loadServerResources(serverUrl string, context string, kinds []string) (serverResources, error) {
+	loaded := serverResources{resources: map[string]map[string]map[string]interface{}{}}
+	api.UseServer(serverUrl)
+	for _, kind := range backupKinds {
+		if !helpers.StringInSlice(kind.kind, kinds) {
+			continue
+		}
+		resources, err := getAllResourceFields(kind)
+		if err != nil {
+			return loaded, fmt.Errorf("failed to get the %s resources of '%s': %v", kind.kind, context, err)
+		}
+		loaded.resources[kind.kind] = map[string]map[string]interface{}{}
+		for _, resource := range resources {
+			name, _ := resource[manifestNameField(kind.kind)].(string)
+			loaded.resources[kind.kind][name] = NormalizeResource(resource, nil)
+			if kind.kind == "rule" {
+				loaded.ruleOrder = append(loaded.ruleOrder, name)
+			}
+		}
+	}
+	return loaded, nil
+}
+
+func sortedResourceNames(resources ...map[string]map[string]interface{}) []string {
+	var names []string
+	for _, kindResources := range resources {
+		for name := range kindResources {
+			if !helpers.StringInSlice(name, names) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compare lists the resources that differ between the two contexts and
+// whether the order of their common rules differs, true is returned when
+// any differences were found.
+func Compare(options MigrateOptions) (bool, error) {
+	kinds, err := parseKinds(options.Kinds)
+	if err != nil {
+		return false, err
+	}
+	sourceUrl, targetUrl, err := migrateServers(options)
+	if err != nil {
+		return false, err
+	}
+	source, err := loadServerResources(sourceUrl, options.From, kinds)
+	if err != nil {
+		return false, err
+	}
+	target, err := loadServerResources(targetUrl, options.To, kinds)
+	if err != nil {
+		return false, err
+	}
+
+	var results []CompareResult
+	var diffs [][]string
+	for _, kind := range kinds {
+		for _, name := range sortedResourceNames(source.resources[kind], target.resources[kind]) {
+			if len(options.Names) > 0 && !helpers.StringInSlice(name, options.Names) {
+				continue
+			}
+			sourceResource, inSource := source.resources[kind][name]
+			targetResource, inTarget := target.resources[kind][name]
+			switch {
+			case !inTarget:
+				results = append(results, CompareResult{kind, name, onlyInSource})
+			case !inSource:
+				results = append(results, CompareResult{kind, name, onlyInTarget})
+			default:
+				maskedSource, maskedTarget := maskPasswords(sourceResource, targetResource)
+				sourceYaml, _ := helpers.EncodeToYaml(maskedSource)
+				targetYaml, _ := helpers.EncodeToYaml(maskedTarget)
+				lines := helpers.UnifiedDiff(
+					fmt.Sprintf("%s/%s/%s", options.From, kind, name), fmt.Sprintf("%s/%s/%s", options.To, kind, name),
+					string(sourceYaml), string(targetYaml))
+				if len(lines) > 0 {
+					results = append(results, CompareResult{kind, name, differs})
+					diffs = append(diffs, lines)
+				}
+			}
+		}
+	}
+
+	var sourceOrder, targetOrder []string
+	for _, name := range source.ruleOrder {
+		if helpers.StringInSlice(name, target.ruleOrder) && (len(options.Names) == 0 || helpers.StringInSlice(name, options.Names)) {
+			sourceOrder = append(sourceOrder, name)
+		}
+	}
+	for _, name := range target.ruleOrder {
+		if helpers.StringInSlice(name, sourceOrder) {
+			targetOrder = append(targetOrder, name)
+		}
+	}
+	orderDiffers := strings.Join(sourceOrder, "\n") != strings.Join(targetOrder, "\n")
+
+	if len(results) == 0 && !orderDiffers {
+		fmt.Printf("No differences between '%s' and '%s'\n", options.From, options.To)
+		return false, nil
+	}
+	if len(results) > 0 {
+		helpers.PrintTable(results)
+	}
+	if orderDiffers {
+		fmt.Printf("Rule order differs:\n  %s: %s\n  %s: %s\n", options.From, strings.Join(sourceOrder, ", "), options.To, strings.Join(targetOrder, ", "))
+	}
+	if options.Diff {
+		for _, lines := range diffs {
+			helpers.PrintDiff(lines)
+		}
+	}
+	return true, nil
+}
+
+// Migrate copies the selected resources from one context to the other, the
+// cred references of devices are renamed by the cred map and the migrated
+// rules are placed like they're ordered on the source server. The plan is
+// printed first and only carried out when Yes is set.
+func Migrate(options MigrateOptions) error {
+	kinds, err := parseKinds(options.Kinds)
+	if err != nil {
+		return err
+	}
+	sourceUrl, targetUrl, err := migrateServers(options)
+	if err != nil {
+		return err
+	}
+	source, err := loadServerResources(sourceUrl, options.From, kinds)
+	if err != nil {
+		return err
+	}
+	// the target's creds and actions are needed to check the references
+	target, err := loadServerResources(targetUrl, options.To, diffableKinds)
+	if err != nil {
+		return err
+	}
+
+	var manifests []Manifest
+	for _, kind := range kinds {
+		for _, name := range sortedResourceNames(source.resources[kind]) {
+			if len(options.Names) > 0 && !helpers.StringInSlice(name, options.Names) {
+				continue
+			}
+			resource := mergeFields(source.resources[kind][name], nil)
+			if kind == "creds" && options.CredMap[name] != "" {
+				resource["name"] = options.CredMap[name]
+			}
+			if credName, _ := resource["creds_name"].(string); kind == "device" && options.CredMap[credName] != "" {
+				resource["creds_name"] = options.CredMap[credName]
+			}
+			resourceName, _ := resource[manifestNameField(kind)].(string)
+			data, _ := json.Marshal(resource)
+			manifests = append(manifests, Manifest{Source: options.From, Kind: kind, Name: resourceName, Data: data})
+		}
+	}
+	if len(manifests) == 0 {
+		return fmt.Errorf("no resources were selected on '%s'", options.From)
+	}
+	err = checkMigrateReferences(manifests, target)
+	if err != nil {
+		return err
+	}
+	manifests, err = SortManifests(manifests)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Plan (%s -> %s):\n", options.From, options.To)
+	changed := 0
+	actions := map[string]string{}
+	for _, manifest := range manifests {
+		var local map[string]interface{}
+		json.Unmarshal(manifest.Data, &local)
+		live := target.resources[manifest.Kind][manifest.Name]
+		lines, err := DiffResource(manifest.Kind, manifest.Name, options.From, local, live)
+		if err != nil {
+			return err
+		}
+		actions[manifest.String()] = "unchanged"
+		if live == nil {
+			actions[manifest.String()] = "create"
+		} else if len(lines) > 0 {
+			actions[manifest.String()] = "update"
+		}
+		if actions[manifest.String()] != "unchanged" {
+			changed++
+		}
+		fmt.Printf("  %s %s\n", actions[manifest.String()], manifest)
+	}
+	rulePlacements := planRulePlacements(manifests, source.ruleOrder, target.ruleOrder)
+	for _, placement := range rulePlacements {
+		fmt.Printf("  place rule/%s\n", placement.describe())
+	}
+	if !options.Yes {
+		fmt.Println("Run again with '--yes' to migrate")
+		return nil
+	}
+
+	api.UseServer(targetUrl)
+	failed := 0
+	for _, manifest := range manifests {
+		if actions[manifest.String()] == "unchanged" {
+			continue
+		}
+		result, err := applyManifest(manifest)
+		if err != nil {
+			failed++
+			fmt.Printf("%s failed: %s\n", manifest, strings.ReplaceAll(err.Error(), "\n", " "))
+		} else {
+			fmt.Printf("%s %s\n", manifest, result)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d resources failed, the rules were not placed", failed, changed)
+	}
+	for _, placement := range rulePlacements {
+		ruleData, _ := json.Marshal(placement.fields())
+		_, err = api.PutResourceFromBytes("rule", ruleData)
+		if err != nil {
+			return fmt.Errorf("failed to place rule '%s': %v", placement.name, err)
+		}
+	}
+	return nil
+}
+
+// checkMigrateReferences makes sure the creds and actions referenced by the
+// migrated resources exist on the target or are migrated too.
+func checkMigrateReferences(manifests []Manifest, target serverResources) error {
+	migrated := map[string]bool{}
+	for _, manifest := range manifests {
+		migrated[manifest.String()] = true
+	}
+	for _, manifest := range manifests {
+		if manifest.Kind != "device" && manifest.Kind != "rule" {
+			continue
+		}
+		for _, key := range manifest.dependencies() {
+			parts := strings.SplitN(key, "/", 2)
+			if parts[0] == "rule" {
+				continue
+			}
+			if _, ok := target.resources[parts[0]][parts[1]]; !ok && !migrated[key] {
+				hint := ""
+				if parts[0] == "creds" {
+					hint = " (use '--cred-map' to use another cred)"
+				}
+				return fmt.Errorf("%s references %s which doesn't exist on the target%s", manifest, key, hint)
+			}
+		}
+	}
+	return nil
+}
+
+type rulePlacement struct {
+	name       string
+	afterRule  string
+	beforeRule string
+}
+
+func (p rulePlacement) describe() string {
+	if p.afterRule != "" {
+		return fmt.Sprintf("%s after %s", p.name, p.afterRule)
+	}
+	return fmt.Sprintf("%s before %s", p.name, p.beforeRule)
+}
+
+func (p rulePlacement) fields() map[string]string {
+	if p.afterRule != "" {
+		return map[string]string{"name": p.name, "after_rule": p.afterRule}
+	}
+	return map[string]string{"name": p.name, "before_rule": p.beforeRule}
+}
+
+// planRulePlacements places each migrated rule after the closest rule that
+// precedes it on the source and exists on the target (or before the closest
+// following one), so the source order is kept.
+func planRulePlacements(manifests []Manifest, sourceOrder []string, targetOrder []string) []rulePlacement {
+	placed := map[string]bool{}
+	targetIdx := map[string]int{}
+	for idx, name := range targetOrder {
+		placed[name] = true
+		targetIdx[name] = idx
+	}
+	migrated := map[string]bool{}
+	for _, manifest := range manifests {
+		if manifest.Kind == "rule" {
+			migrated[manifest.Name] = true
+		}
+	}
+	var placements []rulePlacement
+	for idx, name := range sourceOrder {
+		if !migrated[name] {
+			continue
+		}
+		placement := rulePlacement{name: name}
+		for previous := idx - 1; previous >= 0 && placement.afterRule == ""; previous-- {
+			if placed[sourceOrder[previous]] {
+				placement.afterRule = sourceOrder[previous]
+			}
+		}
+		for next := idx + 1; next < len(sourceOrder) && placement.afterRule == "" && placement.beforeRule == ""; next++ {
+			if placed[sourceOrder[next]] && !migrated[sourceOrder[next]] {
+				placement.beforeRule = sourceOrder[next]
+			}
+		}
+		placed[name] = true
+		if idx, ok := targetIdx[name]; ok {
+			// already placed on the target
+			afterIdx, afterOk := targetIdx[placement.afterRule]
+			beforeIdx, beforeOk := targetIdx[placement.beforeRule]
+			if (afterOk && afterIdx == idx-1) || (beforeOk && beforeIdx == idx+1) {
+				continue
+			}
+		}
+		if placement.afterRule != "" || placement.beforeRule != "" {
+			placements = append(placements, placement)
+		}
+	}
+	return placements
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"vaxctl/helpers"
+
+	"github.com/ghodss/yaml"
+)
+
+// OverlayFilename marks a directory as an overlay, it lists the resources
+// (files, directories or other overlays, e.g. a shared base) and the patches
+// to apply to them:
+//
+//	resources:
+//	  - ../base
+//	patches:
+//	  - devices.yaml
+//	values:
+//	  - values.yaml
+//
+// Patches are documents with a 'kind' and a name that are merged into the
+// matching resource as JSON merge patches (RFC 7386). The values files are
+// the defaults of the template values of the overlay and its resources.
+const OverlayFilename = "vaxctl-overlay.yaml"
+
+type Overlay struct {
+	Resources []string `json:"resources"`
+	Patches   []string `json:"patches"`
+	Values    []string `json:"values"`
+}
+
+func isOverlayDir(path string) bool {
+	info, err := os.Stat(filepath.Join(path, OverlayFilename))
+	return err == nil && !info.IsDir()
+}
+
+// readOverlay resolves the overlay's resources and patches, the parents are
+// the overlays including it which are used to detect loops.
+func readOverlay(dir string, values map[string]interface{}, parents []string) ([]Manifest, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if helpers.StringInSlice(absDir, parents) {
+		return nil, fmt.Errorf("overlay '%s' includes itself", dir)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, OverlayFilename))
+	if err != nil {
+		return nil, err
+	}
+	var overlay Overlay
+	err = yaml.Unmarshal(data, &overlay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %v", filepath.Join(dir, OverlayFilename), err)
+	}
+	if len(overlay.Values) > 0 {
+		var valuesFiles []string
+		for _, filename := range overlay.Values {
+			valuesFiles = append(valuesFiles, filepath.Join(dir, filename))
+		}
+		overlayValues, err := helpers.ReadValues(valuesFiles, nil)
+		if err != nil {
+			return nil, err
+		}
+		// the values of the including overlays and the command line win
+		helpers.MergeValues(overlayValues, values)
+		values = overlayValues
+	}
+
+	var manifests []Manifest
+	for _, resource := range overlay.Resources {
+		path := filepath.Join(dir, resource)
+		var resourceManifests []Manifest
+		if isOverlayDir(path) {
+			resourceManifests, err = readOverlay(path, values, append(parents, absDir))
+		} else {
+			resourceManifests, err = readManifestPath(path, false, values)
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, resourceManifests...)
+	}
+
+	for _, patch := range overlay.Patches {
+		patchManifests, err := readManifestPath(filepath.Join(dir, patch), false, values)
+		if err != nil {
+			return nil, err
+		}
+		for _, patchManifest := range patchManifests {
+			err = applyOverlayPatch(manifests, patchManifest)
+			if err != nil {
+				return nil, fmt.Errorf("patch %s (%s): %v", patchManifest, patchManifest.location(), err)
+			}
+		}
+	}
+	return manifests, nil
+}
+
+func applyOverlayPatch(manifests []Manifest, patch Manifest) error {
+	if patch.Err != nil {
+		return patch.Err
+	}
+	if patch.Name == "" {
+		return fmt.Errorf("'%s' is missing", manifestNameField(patch.Kind))
+	}
+	for idx := range manifests {
+		if manifests[idx].Err != nil || manifests[idx].String() != patch.String() {
+			continue
+		}
+		patchedData, err := helpers.MergePatch(manifests[idx].Data, patch.Data)
+		if err != nil {
+			return err
+		}
+		manifests[idx].Data = patchedData
+		return nil
+	}
+	return fmt.Errorf("no resource matches the patch")
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// PatchTypes are the supported patch formats: JSON merge patch (RFC 7386)
+// and JSON patch (RFC 6902).
+var PatchTypes = []string{"merge", "json"}
+
+// PatchResource applies the patch (JSON or YAML) to the fetched resource and
+// sends the result, the patched resource is validated like 'set'.
+func PatchResource(kindValue string, name string, patchType string, patch string) error {
+	kind, ok := manifestKinds[strings.ToLower(kindValue)]
+	if !ok || !helpers.StringInSlice(kind, diffableKinds) {
+		return fmt.Errorf("kind '%s' can't be patched (allowed values are: cred, device, action, rule & macro)", kindValue)
+	}
+	patchData, err := helpers.ToJSON([]byte(patch))
+	if err != nil {
+		return fmt.Errorf("failed to parse the patch: %v", err)
+	}
+	return patchResource(kind, name, patchType, patchData, "patched")
+}
+
+// LabelDevice sets ('KEY=VALUE') and removes ('KEY-') metadata keys of a
+// device with a merge patch.
+func LabelDevice(uid string, labels []string) error {
+	metadata := map[string]interface{}{}
+	for _, label := range labels {
+		if strings.HasSuffix(label, "-") && !strings.Contains(label, "=") {
+			metadata[strings.TrimSuffix(label, "-")] = nil
+			continue
+		}
+		keyValue := strings.SplitN(label, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			return fmt.Errorf("label '%s' must be KEY=VALUE or KEY-", label)
+		}
+		metadata[keyValue[0]] = keyValue[1]
+	}
+	patchData, _ := json.Marshal(map[string]interface{}{"metadata": metadata})
+	return patchResource("device", uid, "merge", patchData, "labeled")
+}
+
+func patchResource(kind string, name string, patchType string, patchData []byte, result string) error {
+	live, err := GetResourceFields(kind, name)
+	if err != nil {
+		return err
+	}
+	if live == nil {
+		return fmt.Errorf("%s '%s' was not found", kind, name)
+	}
+	live = NormalizeResource(live, nil)
+	liveData, _ := json.Marshal(live)
+	var patchedData []byte
+	switch patchType {
+	case "merge":
+		if !strings.HasPrefix(strings.TrimSpace(string(patchData)), "{") {
+			return fmt.Errorf("a merge patch must be an object (use '--type json' for a JSON patch)")
+		}
+		patchedData, err = helpers.MergePatch(liveData, patchData)
+	case "json":
+		patchedData, err = helpers.JSONPatch(liveData, patchData)
+	default:
+		return fmt.Errorf("patch type '%s' is not valid (allowed values are: merge & json)", patchType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply the patch: %v", err)
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(patchedData, &fields)
+	if err != nil || fields == nil {
+		return fmt.Errorf("the patched %s must be an object", kind)
+	}
+	if kind == "device" && !strings.Contains(string(patchData), syncMetadataKey) {
+		// only a patch naming the sync tag removes it
+		keepSyncTag(live, fields)
+	}
+	manifest := Manifest{Kind: kind, Name: name}
+	if fields[manifestNameField(kind)] != name {
+		return fmt.Errorf("'%s' can't be patched", manifestNameField(kind))
+	}
+	if reflect.DeepEqual(fields, live) {
+		fmt.Printf("%s %s (no change)\n", manifest, result)
+		return nil
+	}
+	err = validateResourceFields(kind, fields)
+	if err != nil {
+		return fmt.Errorf("%s is invalid:\n%v", manifest, err)
+	}
+	expansions, err := expandRuleFields(kind, fields)
+	if err != nil {
+		return err
+	}
+	manifest.Data, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if kind == "macro" {
+		_, err = saveMacro(manifest)
+	} else {
+		_, err = api.PutResourceFromBytes(kind, manifest.Data)
+	}
+	if err != nil {
+		return err
+	}
+	printResourceResult(manifest, result)
+	if len(expansions) == 0 {
+		return nil
+	}
+	return recordMacroExpansions(name, expansions)
+}
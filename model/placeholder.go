@@ -0,0 +1,211 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"vaxctl/helpers"
+)
+
+const maskedPassword = "********"
+
+// placeholderRegex matches the device attributes used in action data, e.g.
+// '{device::uid}' or '{cred_store::CRED_NAME::password}'.
+var placeholderRegex = regexp.MustCompile(`\{([a-z_]+)::([^{}]*)\}`)
+
+// RenderedAction is an action with its data as it would be run on a device.
+type RenderedAction struct {
+	Name string `json:"name" yaml:"name" header:"Name"`
+	Type string `json:"action_type" yaml:"action_type" header:"Type"`
+	Data string `json:"action_data" yaml:"action_data" header:"Data"`
+}
+
+type placeholderResolver struct {
+	device        Device
+	creds         []Cred
+	showPasswords bool
+}
+
+func newPlaceholderResolver(deviceUid string, showPasswords bool) (*placeholderResolver, error) {
+	devices, err := GetDevices(deviceUid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device '%s': %v", deviceUid, err)
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("device '%s' not found", deviceUid)
+	}
+	creds, err := GetCreds("")
+	if err != nil {
+		return nil, err
+	}
+	return &placeholderResolver{device: devices[0], creds: creds, showPasswords: showPasswords}, nil
+}
+
+// deviceCred returns the cred of the device, the default cred when it
+// doesn't have one.
+func (r *placeholderResolver) deviceCred() (Cred, error) {
+	for _, cred := range r.creds {
+		if r.device.CredsName == cred.Name || ((r.device.CredsName == "" || r.device.CredsName == "default") && cred.IsDefault) {
+			return cred, nil
+		}
+	}
+	if r.device.CredsName == "" || r.device.CredsName == "default" {
+		return Cred{}, fmt.Errorf("device '%s' has no cred and no default cred is set", r.device.UID)
+	}
+	return Cred{}, fmt.Errorf("cred '%s' of device '%s' not found", r.device.CredsName, r.device.UID)
+}
+
+func (r *placeholderResolver) credField(cred Cred, field string) (string, error) {
+	switch field {
+	case "username":
+		return cred.Username, nil
+	case "password":
+		if r.showPasswords {
+			return cred.Password, nil
+		}
+		return maskedPassword, nil
+	}
+	return "", fmt.Errorf("unknown cred key '%s' (allowed values are: username & password)", field)
+}
+
+func (r *placeholderResolver) resolve(baseKey string, nestedKey string) (string, error) {
+	switch baseKey {
+	case "device":
+		switch nestedKey {
+		case "uid":
+			return r.device.UID, nil
+		case "ipmi_ip":
+			return r.device.IpmiIp, nil
+		case "model":
+			return r.device.Model, nil
+		}
+		return "", fmt.Errorf("unknown device key '%s' (allowed values are: uid, ipmi_ip & model)", nestedKey)
+	case "metadata":
+		value, ok := r.device.Metadata[nestedKey]
+		if !ok {
+			return "", fmt.Errorf("device '%s' has no metadata key '%s'", r.device.UID, nestedKey)
+		}
+		return value, nil
+	case "cred":
+		cred, err := r.deviceCred()
+		if err != nil {
+			return "", err
+		}
+		return r.credField(cred, nestedKey)
+	case "cred_store":
+		keys := strings.SplitN(nestedKey, "::", 2)
+		if len(keys) != 2 {
+			return "", fmt.Errorf("cred store key '%s' must be CRED_NAME::username or CRED_NAME::password", nestedKey)
+		}
+		for _, cred := range r.creds {
+			if cred.Name == keys[0] {
+				return r.credField(cred, keys[1])
+			}
+		}
+		return "", fmt.Errorf("cred '%s' not found", keys[0])
+	}
+	return "", fmt.Errorf("unknown base key '%s' (allowed values are: device, metadata, cred & cred_store)", baseKey)
+}
+
+// render replaces every placeholder of the data, all failed placeholders are
+// reported.
+func (r *placeholderResolver) render(data string) (string, error) {
+	var errs []string
+	rendered := placeholderRegex.ReplaceAllStringFunc(data, func(placeholder string) string {
+		keys := placeholderRegex.FindStringSubmatch(placeholder)
+		value, err := r.resolve(keys[1], keys[2])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", placeholder, err))
+			return placeholder
+		}
+		return value
+	})
+	if len(errs) > 0 {
+		return rendered, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return rendered, nil
+}
+
+func (r *placeholderResolver) renderActions(actions []Action) ([]RenderedAction, error) {
+	var renderedActions []RenderedAction
+	var errs []string
+	for _, action := range actions {
+		data, err := r.render(action.Data)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("action '%s' failed to render:\n%v", action.Name, err))
+		}
+		renderedActions = append(renderedActions, RenderedAction{Name: action.Name, Type: action.Type, Data: data})
+	}
+	if len(errs) > 0 {
+		return renderedActions, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return renderedActions, nil
+}
+
+// RenderAction prints the data of an action with the placeholders resolved
+// for the device, passwords are masked unless shown.
+func RenderAction(name string, deviceUid string, showPasswords bool, output string) error {
+	actions, err := GetActions(name)
+	if err != nil {
+		return err
+	}
+	resolver, err := newPlaceholderResolver(deviceUid, showPasswords)
+	if err != nil {
+		return err
+	}
+	renderedActions, err := resolver.renderActions(actions)
+	if err != nil {
+		return err
+	}
+	return printRenderedActions(renderedActions, output)
+}
+
+// RenderRule prints the actions of a rule in order with the placeholders
+// resolved for the device, passwords are masked unless shown.
+func RenderRule(name string, deviceUid string, showPasswords bool, output string) error {
+	rules, err := GetRules(name)
+	if err != nil {
+		return err
+	}
+	resolver, err := newPlaceholderResolver(deviceUid, showPasswords)
+	if err != nil {
+		return err
+	}
+	actions, err := getRuleActions(rules[0])
+	if err != nil {
+		return err
+	}
+	renderedActions, err := resolver.renderActions(actions)
+	if err != nil {
+		return err
+	}
+	return printRenderedActions(renderedActions, output)
+}
+
+// getRuleActions returns the actions of the rule in order.
+func getRuleActions(rule Rule) ([]Action, error) {
+	var actions []Action
+	for _, actionName := range rule.Actions {
+		action, err := GetActions(actionName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get action '%s' of rule '%s': %v", actionName, rule.Name, err)
+		}
+		actions = append(actions, action...)
+	}
+	return actions, nil
+}
+
+func printRenderedActions(reportObject []RenderedAction, output string) error {
+	switch output {
+	case "json":
+		returnObject, _ := json.MarshalIndent(reportObject, "", "  ")
+		fmt.Println(string(returnObject))
+	case "yaml":
+		returnObject, _ := helpers.EncodeToYaml(reportObject)
+		fmt.Println(string(returnObject))
+	default:
+		helpers.PrintTable(reportObject)
+	}
+	return nil
+}
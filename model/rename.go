@@ -0,0 +1,249 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+// renameUndo reverts a step of a rename.
+type renameUndo struct {
+	manifest Manifest
+	call     func() error
+}
+
+// RenameResource renames an action, cred or rule: the new resource is
+// created (rules in the position of the old one), the references of rules,
+// devices and macros are rewritten and the old resource is deleted. When a
+// step fails the previous ones are rolled back. The macro references
+// recorded for rules follow the new name.
+func RenameResource(kindValue string, oldName string, newName string) error {
+	kind := manifestKinds[strings.ToLower(kindValue)]
+	if kind != "action" && kind != "creds" && kind != "rule" {
+		return fmt.Errorf("kind '%s' can't be renamed (allowed values are: cred, action & rule)", kindValue)
+	}
+	if oldName == newName {
+		return fmt.Errorf("the new name is the same as the old one")
+	}
+	live, err := GetResourceFields(kind, oldName)
+	if err != nil {
+		return err
+	}
+	if live == nil {
+		return fmt.Errorf("%s '%s' was not found", kind, oldName)
+	}
+	existing, err := GetResourceFields(kind, newName)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%s '%s' already exists", kind, newName)
+	}
+
+	var undos []renameUndo
+	err = renameSteps(kind, oldName, newName, NormalizeResource(live, nil), &undos)
+	if err == nil {
+		err = renameMacroExpansions(kind, oldName, newName)
+		if err != nil {
+			return fmt.Errorf("%s '%s' was renamed but its recorded macro references were not: %v", kind, oldName, err)
+		}
+		return nil
+	}
+	fmt.Printf("rename failed, rolling back: %v\n", strings.ReplaceAll(err.Error(), "\n", " "))
+	for idx := len(undos) - 1; idx >= 0; idx-- {
+		undoErr := undos[idx].call()
+		if undoErr != nil {
+			fmt.Printf("%s rollback failed: %v\n", undos[idx].manifest, undoErr)
+		} else {
+			printResourceResult(undos[idx].manifest, "rolled back")
+		}
+	}
+	return fmt.Errorf("failed to rename %s '%s' to '%s': %v", kind, oldName, newName, err)
+}
+
+func renameSteps(kind string, oldName string, newName string, fields map[string]interface{}, undos *[]renameUndo) error {
+	newManifest := Manifest{Kind: kind, Name: newName}
+	fields["name"] = newName
+	isDefault, _ := fields["is_default"].(bool)
+	delete(fields, "is_default")
+	if kind == "rule" {
+		fields["before_rule"] = oldName
+	}
+	data, _ := json.Marshal(fields)
+	_, err := api.PostResourceFromBytes(kind, data)
+	if err != nil {
+		return err
+	}
+	printResourceResult(newManifest, "created")
+	*undos = append(*undos, renameUndo{newManifest, func() error {
+		_, err := api.DeleteResource(kind, newName)
+		return err
+	}})
+	if isDefault {
+		err = SetCredsAsDefault(newName)
+		if err != nil {
+			return err
+		}
+		printResourceResult(newManifest, "set as default")
+		*undos = append(*undos, renameUndo{Manifest{Kind: kind, Name: oldName}, func() error {
+			return SetCredsAsDefault(oldName)
+		}})
+	}
+
+	switch kind {
+	case "action":
+		err = renameRuleActions(oldName, newName, undos)
+		if err == nil {
+			err = renameMacroActions(oldName, newName, undos)
+		}
+	case "creds":
+		err = renameDeviceCreds(oldName, newName, undos)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = api.DeleteResource(kind, oldName)
+	if err != nil {
+		return err
+	}
+	printResourceResult(Manifest{Kind: kind, Name: oldName}, "deleted")
+	return nil
+}
+
+// putRenamedReference sends the changed fields of a referencing resource, the
+// original ones are sent back on rollback.
+func putRenamedReference(kind string, name string, original map[string]interface{}, changed map[string]interface{}, undos *[]renameUndo) error {
+	manifest := Manifest{Kind: kind, Name: name}
+	data, _ := json.Marshal(changed)
+	_, err := api.PutResourceFromBytes(kind, data)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %v", manifest, err)
+	}
+	printResourceResult(manifest, "configured")
+	originalData, _ := json.Marshal(original)
+	*undos = append(*undos, renameUndo{manifest, func() error {
+		_, err := api.PutResourceFromBytes(kind, originalData)
+		return err
+	}})
+	return nil
+}
+
+func renameRuleActions(oldName string, newName string, undos *[]renameUndo) error {
+	rules, err := GetRules("")
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if !helpers.StringInSlice(oldName, rule.Actions) {
+			continue
+		}
+		live, err := GetResourceFields("rule", rule.Name)
+		if err != nil {
+			return err
+		}
+		original := NormalizeResource(live, nil)
+		changed := NormalizeResource(live, nil)
+		var actions []string
+		for _, action := range rule.Actions {
+			if action == oldName {
+				action = newName
+			}
+			actions = append(actions, action)
+		}
+		changed["actions"] = actions
+		err = putRenamedReference("rule", rule.Name, original, changed, undos)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renameDeviceCreds(oldName string, newName string, undos *[]renameUndo) error {
+	devices, err := GetDevices("")
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.CredsName != oldName {
+			continue
+		}
+		live, err := GetResourceFields("device", device.UID)
+		if err != nil {
+			return err
+		}
+		original := NormalizeResource(live, nil)
+		changed := NormalizeResource(live, nil)
+		changed["creds_name"] = newName
+		err = putRenamedReference("device", device.UID, original, changed, undos)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameMacroActions rewrites the action names listed by the stored macros.
+func renameMacroActions(oldName string, newName string, undos *[]renameUndo) error {
+	macros, err := readMacroStore()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fields := macros[name]
+		items, _ := fields["actions"].([]interface{})
+		changed := false
+		var renamedItems []interface{}
+		for _, item := range items {
+			switch value := item.(type) {
+			case string:
+				if value == oldName {
+					item = newName
+					changed = true
+				}
+			case map[string]interface{}:
+				if value["action"] == oldName {
+					renamedItem := map[string]interface{}{}
+					for key, itemValue := range value {
+						renamedItem[key] = itemValue
+					}
+					renamedItem["action"] = newName
+					item = renamedItem
+					changed = true
+				}
+			}
+			renamedItems = append(renamedItems, item)
+		}
+		if !changed {
+			continue
+		}
+		renamed := map[string]interface{}{}
+		for key, value := range fields {
+			renamed[key] = value
+		}
+		renamed["actions"] = renamedItems
+		manifest := Manifest{Kind: "macro", Name: name}
+		manifest.Data, _ = json.Marshal(renamed)
+		_, err = saveMacro(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %v", manifest, err)
+		}
+		printResourceResult(manifest, "configured")
+		originalManifest := Manifest{Kind: "macro", Name: name}
+		originalManifest.Data, _ = json.Marshal(fields)
+		*undos = append(*undos, renameUndo{manifest, func() error {
+			_, err := saveMacro(originalManifest)
+			return err
+		}})
+	}
+	return nil
+}
@@ -101,20 +101,32 @@ func GetRuleNames() ([]string, error) {
 }
 
 func GenerateRule(filename string, mandatoryFlag bool, commentsFlag bool) error {
-	allActions, err := GetActionNames()
+	props, err := ruleProps(true)
 	if err != nil {
 		return err
 	}
+	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
+}
+
+// ruleProps returns the props of a rule, the action and rule names are only
+// listed when online.
+func ruleProps(online bool) ([]helpers.PropInfo, error) {
 	actionsItemsData := make(map[string]interface{})
 	actionsItemsData["type"] = "string"
-	actionsItemsData["enum"] = allActions
+	afterRuleConstraint := make(map[string]interface{})
+	if online {
+		allActions, err := GetActionNames()
+		if err != nil {
+			return nil, err
+		}
+		actionsItemsData["enum"] = allActions
 
-	allRules, err := GetRuleNames()
-	if err != nil {
-		return err
+		allRules, err := GetRuleNames()
+		if err != nil {
+			return nil, err
+		}
+		afterRuleConstraint["enum"] = allRules
 	}
-	afterRuleConstraint := make(map[string]interface{})
-	afterRuleConstraint["enum"] = allRules
 	afterRuleConstraint["unique"] = "only one of [before_rule, after_rule] can be set"
 
 	stateOrScreenshotConstraint := make(map[string]interface{})
@@ -128,18 +140,22 @@ func GenerateRule(filename string, mandatoryFlag bool, commentsFlag bool) error
 			Mandatory: true,
 		},
 		{
-			Name:        "state_id",
-			Type:        "integer",
-			Desc:        "ID of the state from which to take the screenshot from",
-			Mandatory:   false,
-			Constraints: stateOrScreenshotConstraint,
+			Name:              "state_id",
+			Type:              "integer",
+			Desc:              "ID of the state from which to take the screenshot from",
+			Mandatory:         false,
+			Constraints:       stateOrScreenshotConstraint,
+			Exclusive:         []string{"state_id", "screenshot"},
+			ExclusiveRequired: true,
 		},
 		{
-			Name:        "Screenshot",
-			Type:        "string",
-			Desc:        "base64 string of the screenshot image",
-			Mandatory:   false,
-			Constraints: stateOrScreenshotConstraint,
+			Name:              "screenshot",
+			Type:              "string",
+			Desc:              "base64 string of the screenshot image",
+			Mandatory:         false,
+			Constraints:       stateOrScreenshotConstraint,
+			Exclusive:         []string{"state_id", "screenshot"},
+			ExclusiveRequired: true,
 		},
 		{
 			Name:      "regex",
@@ -174,6 +190,7 @@ func GenerateRule(filename string, mandatoryFlag bool, commentsFlag bool) error
 			Desc:        "after which rule name should it be placed (if not set new rules will be added last)",
 			Mandatory:   false,
 			Constraints: afterRuleConstraint,
+			Exclusive:   []string{"before_rule", "after_rule"},
 		},
 		{
 			Name:        "before_rule",
@@ -181,7 +198,8 @@ func GenerateRule(filename string, mandatoryFlag bool, commentsFlag bool) error
 			Desc:        "before which rule name should it be placed (if not set new rules will be added last)",
 			Mandatory:   false,
 			Constraints: afterRuleConstraint,
+			Exclusive:   []string{"before_rule", "after_rule"},
 		},
 	}
-	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
+	return props, nil
 }
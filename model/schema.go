@@ -0,0 +1,153 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"vaxctl/helpers"
+)
+
+// workProps describes a work assignment, either the actions or the rule
+// whose actions should be run.
+func workProps() []helpers.PropInfo {
+	return []helpers.PropInfo{
+		{
+			Name:      "device_uid",
+			Type:      "string",
+			Desc:      "UID of the device to assign the work to",
+			Mandatory: true,
+		},
+		{
+			Name:      "actions",
+			Type:      "array",
+			Desc:      "list of actions to run",
+			Mandatory: false,
+			ItemsData: map[string]interface{}{"type": "string"},
+			Exclusive: []string{"actions", "rule"},
+		},
+		{
+			Name:      "rule",
+			Type:      "string",
+			Desc:      "rule whose actions to run",
+			Mandatory: false,
+			Exclusive: []string{"actions", "rule"},
+		},
+	}
+}
+
+// macroProps describes a macro, its items are action names (or macro
+// references), inline actions or nested macros.
+func macroProps() []helpers.PropInfo {
+	return []helpers.PropInfo{
+		{
+			Name:      "name",
+			Type:      "string",
+			Desc:      "logical name of the macro (referenced as 'macro:NAME' or 'macro:NAME(PARAM=VALUE,...)')",
+			Mandatory: true,
+		},
+		{
+			Name:      "params",
+			Type:      "object",
+			Desc:      "default values of the params used as '${PARAM}' in the items (null if it must be set)",
+			Mandatory: false,
+		},
+		{
+			Name:      "actions",
+			Type:      "array",
+			Desc:      "list of action names, inline actions ('action_type', 'action_data') or nested macros ('macro', 'params')",
+			Mandatory: true,
+		},
+	}
+}
+
+// resourceProps returns the props of a kind, the names of other resources
+// are only listed as enums when online.
+func resourceProps(kind string, online bool) ([]helpers.PropInfo, error) {
+	switch kind {
+	case "creds":
+		return credProps(), nil
+	case "device":
+		return deviceProps(online)
+	case "action":
+		return actionProps(online)
+	case "rule":
+		return ruleProps(online)
+	case "state":
+		return stateProps(), nil
+	case "work":
+		return workProps(), nil
+	case "macro":
+		return macroProps(), nil
+	}
+	return nil, fmt.Errorf("kind '%s' is not valid (allowed values are: cred, device, action, rule, state, work & macro)", kind)
+}
+
+// GenerateSchema writes the JSON Schema of a kind for editors, with the
+// names of the existing resources as enums unless offline.
+func GenerateSchema(kindValue string, filename string, offline bool) error {
+	kind, ok := manifestKinds[strings.ToLower(kindValue)]
+	if !ok {
+		kind = kindValue
+	}
+	props, err := resourceProps(kind, !offline)
+	if err != nil {
+		return err
+	}
+	var kindNames []string
+	for name, manifestKind := range manifestKinds {
+		if manifestKind == kind {
+			kindNames = append(kindNames, name)
+		}
+	}
+	sort.Strings(kindNames)
+	schema := helpers.GenerateSchema("vaxctl "+kind, kindNames, props)
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if filename == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// ValidateManifests checks the documents against the schemas of their kinds
+// without contacting the server, every invalid document is reported.
+func ValidateManifests(source ManifestSource) error {
+	manifests, err := ReadManifests(source)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, manifest := range manifests {
+		var errs []error
+		if manifest.Err != nil {
+			errs = append(errs, manifest.Err)
+		} else {
+			var fields map[string]interface{}
+			json.Unmarshal(manifest.Data, &fields)
+			props, _ := resourceProps(manifest.Kind, false)
+			errs = helpers.ValidateProps(props, fields)
+			if manifest.Kind == "action" {
+				errs = append(errs, validateKeystrokeManifest(fields)...)
+			}
+		}
+		if len(errs) == 0 {
+			fmt.Printf("%s valid\n", manifest)
+			continue
+		}
+		failed++
+		fmt.Printf("%s invalid (%s):\n", manifest, manifest.location())
+		for _, err := range errs {
+			fmt.Printf("  - %s\n", strings.ReplaceAll(err.Error(), "\n", " "))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d documents are invalid", failed, len(manifests))
+	}
+	return nil
+}
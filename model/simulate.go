@@ -0,0 +1,230 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"vaxctl/api"
+	"vaxctl/helpers"
+)
+
+const (
+	simulatedAgentVersion = "vaxctl-simulator"
+	successStatus         = "success"
+	failureStatus         = "failure"
+	pendingWorkStatus     = "PENDING"
+)
+
+type SimulateAgentOptions struct {
+	DeviceUID  string
+	IpmiIp     string
+	Model      string
+	ScreensDir string
+	ScriptFile string
+	Interval   time.Duration
+	Iterations int
+}
+
+// AgentScript sets the reported status of each executed action, actions
+// not listed get the default status.
+type AgentScript struct {
+	Default     string            `json:"default" yaml:"default"`
+	Actions     map[string]string `json:"actions" yaml:"actions"`
+	ElapsedTime float32           `json:"elapsed_time" yaml:"elapsed_time"`
+}
+
+type heartbeatData struct {
+	UID          string `json:"uid"`
+	AgentVersion string `json:"agent_version"`
+}
+
+type simulatedState struct {
+	DeviceUID  string `json:"device_uid"`
+	Screenshot string `json:"screenshot"`
+	OcrText    string `json:"ocr_text,omitempty"`
+}
+
+type simulatedScreen struct {
+	name       string
+	screenshot string
+	ocrText    string
+}
+
+func SimulateAgent(options SimulateAgentOptions) error {
+	script, err := readAgentScript(options.ScriptFile)
+	if err != nil {
+		return err
+	}
+	screens, err := readSimulatedScreens(options.ScreensDir)
+	if err != nil {
+		return err
+	}
+	err = registerSimulatedDevice(options)
+	if err != nil {
+		return err
+	}
+
+	for iteration := 0; options.Iterations == 0 || iteration < options.Iterations; iteration++ {
+		screen := screens[iteration%len(screens)]
+		heartbeat, _ := json.Marshal(heartbeatData{UID: options.DeviceUID, AgentVersion: simulatedAgentVersion})
+		_, err = api.SendHeartbeat(heartbeat)
+		if err != nil {
+			return err
+		}
+
+		stateData, _ := json.Marshal(simulatedState{DeviceUID: options.DeviceUID, Screenshot: screen.screenshot, OcrText: screen.ocrText})
+		responseData, err := api.PutResourceFromBytes("state", stateData)
+		if err != nil {
+			return err
+		}
+		var statesResponse StatesResponse
+		json.Unmarshal(responseData, &statesResponse)
+		matchedRule := ""
+		if len(statesResponse.States) > 0 {
+			matchedRule = statesResponse.States[0].MatchedRule
+		}
+		fmt.Printf("[%s] posted state from '%s' (matched rule: '%s')\n", time.Now().Format(time.RFC3339), screen.name, matchedRule)
+
+		err = runPendingWork(options.DeviceUID, script)
+		if err != nil {
+			return err
+		}
+		if options.Iterations == 0 || iteration < options.Iterations-1 {
+			time.Sleep(options.Interval)
+		}
+	}
+	return nil
+}
+
+func readAgentScript(filename string) (AgentScript, error) {
+	script := AgentScript{Default: successStatus}
+	if filename != "" {
+		scriptData, err := helpers.ReadFileToJSON(filename)
+		if err != nil {
+			return script, err
+		}
+		err = json.Unmarshal(scriptData, &script)
+		if err != nil {
+			return script, err
+		}
+	}
+	if script.Default == "" {
+		script.Default = successStatus
+	}
+	for _, status := range append([]string{script.Default}, mapValues(script.Actions)...) {
+		if status != successStatus && status != failureStatus {
+			return script, fmt.Errorf("status '%s' is not valid (allowed values are: success & failure)", status)
+		}
+	}
+	return script, nil
+}
+
+// readSimulatedScreens loads the PNG files in name order, a '.txt' file with
+// the same name is sent as the OCR text (used by servers without OCR).
+func readSimulatedScreens(screensDir string) ([]simulatedScreen, error) {
+	filenames, err := filepath.Glob(filepath.Join(screensDir, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no PNG files were found in '%s'", screensDir)
+	}
+	sort.Strings(filenames)
+	var screens []simulatedScreen
+	for _, filename := range filenames {
+		screenshot, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		ocrText, _ := ioutil.ReadFile(strings.TrimSuffix(filename, filepath.Ext(filename)) + ".txt")
+		screens = append(screens, simulatedScreen{
+			name:       filepath.Base(filename),
+			screenshot: base64.StdEncoding.EncodeToString(screenshot),
+			ocrText:    string(ocrText),
+		})
+	}
+	return screens, nil
+}
+
+func registerSimulatedDevice(options SimulateAgentOptions) error {
+	_, err := api.GetResourceByUID("device", options.DeviceUID)
+	if err == nil {
+		return nil
+	}
+	if errVal, ok := err.(*api.HttpError); !ok || errVal.Status != http.StatusNotFound {
+		return err
+	}
+	deviceData, _ := json.Marshal(Device{
+		UID:      options.DeviceUID,
+		IpmiIp:   options.IpmiIp,
+		Model:    options.Model,
+		Zombie:   true,
+		Metadata: map[string]string{"simulated": "true"},
+	})
+	_, err = api.PostResourceFromBytes("device", deviceData)
+	if err == nil {
+		fmt.Printf("registered device '%s'\n", options.DeviceUID)
+	}
+	return err
+}
+
+// runPendingWork reports an execution for each action of the device's pending
+// work, stopping at the first failure, and then completes the work.
+func runPendingWork(deviceUID string, script AgentScript) error {
+	responseData, err := api.GetWorksByDevice(deviceUID)
+	if err != nil {
+		return err
+	}
+	var worksResponse WorksResponse
+	json.Unmarshal(responseData, &worksResponse)
+	for _, work := range worksResponse.Works {
+		if work.Status != pendingWorkStatus {
+			continue
+		}
+		workStatus := successStatus
+		for _, action := range work.Actions {
+			status, ok := script.Actions[action.Name]
+			if !ok {
+				status = script.Default
+			}
+			executionData, _ := json.Marshal(Execution{
+				WorkId:      work.Id,
+				ActionNmae:  action.Name,
+				Trigger:     work.Trigger,
+				Status:      status,
+				ElapsedTime: script.ElapsedTime,
+				RunData:     map[string]string{"action_type": action.Type, "action_data": action.Data},
+			})
+			_, err = api.ReportExecutionCompleted(executionData)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  work %d: action '%s' -> %s\n", work.Id, action.Name, status)
+			if status == failureStatus {
+				workStatus = failureStatus
+				break
+			}
+		}
+		workCompletedData, _ := json.Marshal(WorkCompleted{work.Id, workStatus})
+		_, err = api.ReportWorkCompleted(workCompletedData)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  work %d completed with status '%s'\n", work.Id, workStatus)
+	}
+	return nil
+}
+
+func mapValues(data map[string]string) []string {
+	var values []string
+	for _, value := range data {
+		values = append(values, value)
+	}
+	return values
+}
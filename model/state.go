@@ -118,7 +118,11 @@ func UpdateResolvedState(stateId int, resolved bool) error {
 }
 
 func GenerateState(filename string, mandatoryFlag bool, commentsFlag bool) error {
-	props := []helpers.PropInfo{
+	return GenerateResource(stateProps(), filename, mandatoryFlag, commentsFlag)
+}
+
+func stateProps() []helpers.PropInfo {
+	return []helpers.PropInfo{
 		{
 			Name:      "device_uid",
 			Type:      "string",
@@ -126,7 +130,7 @@ func GenerateState(filename string, mandatoryFlag bool, commentsFlag bool) error
 			Mandatory: true,
 		},
 		{
-			Name:      "screnshot",
+			Name:      "screenshot",
 			Type:      "string",
 			Desc:      "base64 string of the screenshot image",
 			Mandatory: true,
@@ -139,5 +143,4 @@ func GenerateState(filename string, mandatoryFlag bool, commentsFlag bool) error
 			DefaultValue: false,
 		},
 	}
-	return GenerateResource(props, filename, mandatoryFlag, commentsFlag)
 }
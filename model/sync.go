@@ -0,0 +1,380 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"vaxctl/api"
+	"vaxctl/helpers"
+
+	"github.com/ghodss/yaml"
+)
+
+// SyncInventoryFilename holds the resources that were synced from a
+// directory, only those are pruned unless all resources should be. Synced
+// devices are also tagged with the syncMetadataKey metadata key on the server
+// so they are pruned without the inventory (e.g. on a fresh checkout).
+const (
+	SyncInventoryFilename = ".vaxctl-sync.yaml"
+	syncMetadataKey       = "vaxctl-sync"
+	syncMetadataValue     = "managed"
+)
+
+// pruneOrder deletes resources before the ones they reference.
+var pruneOrder = []string{"rule", "device", "action", "creds", "macro"}
+
+type SyncOptions struct {
+	Source    ManifestSource
+	Prune     bool
+	PruneAll  bool
+	Yes       bool
+	Inventory string
+}
+
+type SyncInventory struct {
+	Managed []string `json:"managed"`
+}
+
+type syncPlan struct {
+	apply     []Manifest
+	actions   map[string]string
+	ruleOrder []string
+	reorder   bool
+	prune     []Manifest
+}
+
+func (p syncPlan) empty() bool {
+	return len(p.apply) == 0 && !p.reorder && len(p.prune) == 0
+}
+
+// Sync reconciles the server to the manifests: resources that differ are
+// created/updated, rules are ordered like the files and managed resources
+// that were removed from the files are deleted. The plan is printed first
+// and only carried out when Yes is set.
+func Sync(options SyncOptions) error {
+	manifests, err := ReadManifests(options.Source)
+	if err != nil {
+		return err
+	}
+	for _, manifest := range manifests {
+		if err == nil && manifest.Err == nil && !helpers.StringInSlice(manifest.Kind, diffableKinds) {
+			err = fmt.Errorf("%s resources can't be synced", manifest.Kind)
+		}
+		if err == nil {
+			err = manifest.Err
+		}
+		if err == nil && manifest.Name == "" {
+			err = fmt.Errorf("'%s' is missing", manifestNameField(manifest.Kind))
+		}
+		if err != nil {
+			return fmt.Errorf("%s (%s): %v", manifest, manifest.location(), err)
+		}
+	}
+	for idx := range manifests {
+		if manifests[idx].Kind == "device" {
+			manifests[idx].Data = tagSyncedDevice(manifests[idx].Data)
+		}
+	}
+	inventoryFilename := options.Inventory
+	if inventoryFilename == "" {
+		inventoryFilename = defaultInventoryFilename(options.Source.Paths[0])
+	}
+	inventory, err := readSyncInventory(inventoryFilename)
+	if err != nil {
+		return err
+	}
+
+	plan, err := planSync(manifests, options, inventory)
+	if err != nil {
+		return err
+	}
+	if plan.empty() {
+		fmt.Println("Nothing to do, the server is in sync")
+	} else {
+		printSyncPlan(plan)
+		if !options.Yes {
+			fmt.Println("Run again with '--yes' to apply the plan")
+			return nil
+		}
+		err = runSyncPlan(plan)
+		if err != nil {
+			return err
+		}
+	}
+	// the inventory is only changed once the plan was carried out, resources
+	// removed from the files stay in it until they are pruned
+	if !options.Yes || api.IsDryRun() {
+		return nil
+	}
+	return writeSyncInventory(inventoryFilename, syncedInventory(inventory, manifests, plan.prune))
+}
+
+// tagSyncedDevice adds the managed tag to the metadata of a device document.
+func tagSyncedDevice(data []byte) []byte {
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	metadata, _ := fields["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata[syncMetadataKey] = syncMetadataValue
+	fields["metadata"] = metadata
+	taggedData, _ := json.Marshal(fields)
+	return taggedData
+}
+
+// keepSyncTag adds the managed tag of a live device to the metadata replacing
+// its own, so a device managed by sync stays managed when it's applied. True
+// is returned when the fields are changed.
+func keepSyncTag(live map[string]interface{}, fields map[string]interface{}) bool {
+	liveMetadata, _ := live["metadata"].(map[string]interface{})
+	fieldsMetadata, ok := fields["metadata"].(map[string]interface{})
+	if liveMetadata[syncMetadataKey] != syncMetadataValue || !ok {
+		return false
+	}
+	if _, ok := fieldsMetadata[syncMetadataKey]; ok {
+		return false
+	}
+	metadata := map[string]interface{}{syncMetadataKey: syncMetadataValue}
+	for key, value := range fieldsMetadata {
+		metadata[key] = value
+	}
+	fields["metadata"] = metadata
+	return true
+}
+
+// keepLiveSyncTag is keepSyncTag for a device document, the live device is
+// fetched.
+func keepLiveSyncTag(uid string, data []byte) ([]byte, error) {
+	var fields map[string]interface{}
+	if json.Unmarshal(data, &fields) != nil || uid == "" {
+		return data, nil
+	}
+	live, err := GetResourceFields("device", uid)
+	if err != nil || live == nil || !keepSyncTag(live, fields) {
+		return data, err
+	}
+	return json.Marshal(fields)
+}
+
+func defaultInventoryFilename(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, SyncInventoryFilename)
+	}
+	return SyncInventoryFilename
+}
+
+func planSync(manifests []Manifest, options SyncOptions, inventory SyncInventory) (syncPlan, error) {
+	plan := syncPlan{actions: map[string]string{}}
+	sortedManifests, err := SortManifests(manifests)
+	if err != nil {
+		return plan, err
+	}
+	syncedKeys := map[string]bool{}
+	syncedKinds := map[string]bool{}
+	for _, manifest := range sortedManifests {
+		syncedKeys[manifest.String()] = true
+		syncedKinds[manifest.Kind] = true
+		var local map[string]interface{}
+		json.Unmarshal(manifest.Data, &local)
+		live, err := GetResourceFields(manifest.Kind, manifest.Name)
+		if err != nil {
+			return plan, fmt.Errorf("%s: %v", manifest, err)
+		}
+		lines, err := DiffResource(manifest.Kind, manifest.Name, manifest.Source, local, live)
+		if err != nil {
+			return plan, err
+		}
+		if live == nil {
+			plan.actions[manifest.String()] = "create"
+		} else if len(lines) > 0 {
+			plan.actions[manifest.String()] = "update"
+		} else {
+			continue
+		}
+		plan.apply = append(plan.apply, manifest)
+	}
+
+	plan.ruleOrder, plan.reorder, err = planRuleOrder(manifests)
+	if err != nil {
+		return plan, err
+	}
+
+	if options.Prune || options.PruneAll {
+		taggedDevices := map[string]bool{}
+		if syncedKinds["device"] {
+			devices, err := GetDevices("")
+			if err != nil {
+				return plan, err
+			}
+			for _, device := range devices {
+				taggedDevices[device.UID] = device.Metadata[syncMetadataKey] == syncMetadataValue
+			}
+		}
+		for _, kind := range pruneOrder {
+			if !syncedKinds[kind] {
+				continue
+			}
+			names, err := getResourceNames(kind)
+			if err != nil {
+				return plan, err
+			}
+			for _, name := range names {
+				manifest := Manifest{Kind: kind, Name: name}
+				if syncedKeys[manifest.String()] {
+					continue
+				}
+				managed := helpers.StringInSlice(manifest.String(), inventory.Managed) || (kind == "device" && taggedDevices[name])
+				if options.PruneAll || managed {
+					plan.prune = append(plan.prune, manifest)
+				}
+			}
+		}
+	}
+	return plan, nil
+}
+
+// planRuleOrder compares the file order of the rules to their order on the
+// server (new rules are added last), other rules are not considered.
+func planRuleOrder(manifests []Manifest) ([]string, bool, error) {
+	var fileOrder []string
+	for _, manifest := range manifests {
+		if manifest.Kind == "rule" {
+			fileOrder = append(fileOrder, manifest.Name)
+		}
+	}
+	if len(fileOrder) < 2 {
+		return fileOrder, false, nil
+	}
+	rules, err := GetRules("")
+	if err != nil {
+		return nil, false, err
+	}
+	var liveOrder []string
+	for _, rule := range rules {
+		if helpers.StringInSlice(rule.Name, fileOrder) {
+			liveOrder = append(liveOrder, rule.Name)
+		}
+	}
+	for _, name := range fileOrder {
+		if !helpers.StringInSlice(name, liveOrder) {
+			liveOrder = append(liveOrder, name)
+		}
+	}
+	return fileOrder, strings.Join(liveOrder, "\n") != strings.Join(fileOrder, "\n"), nil
+}
+
+func getResourceNames(kind string) ([]string, error) {
+	switch kind {
+	case "creds":
+		return GetCredNames()
+	case "device":
+		return GetDeviceNames()
+	case "action":
+		return GetActionNames()
+	case "macro":
+		return getMacroNames()
+	default:
+		return GetRuleNames()
+	}
+}
+
+func printSyncPlan(plan syncPlan) {
+	fmt.Println("Plan:")
+	for _, manifest := range plan.apply {
+		fmt.Printf("  %s %s\n", plan.actions[manifest.String()], manifest)
+	}
+	if plan.reorder {
+		fmt.Printf("  reorder rules: %s\n", strings.Join(plan.ruleOrder, ", "))
+	}
+	for _, manifest := range plan.prune {
+		fmt.Printf("  delete %s\n", manifest)
+	}
+}
+
+// runSyncPlan stops at the first failure, the following steps may depend on it.
+func runSyncPlan(plan syncPlan) error {
+	for _, manifest := range plan.apply {
+		result, err := applyManifest(manifest)
+		if err != nil {
+			return fmt.Errorf("%s failed (%s): %v", manifest, manifest.location(), err)
+		}
+		fmt.Printf("%s %s\n", manifest, result)
+	}
+	if plan.reorder {
+		err := orderRules(plan.ruleOrder)
+		if err != nil {
+			return err
+		}
+		fmt.Println("rules reordered")
+	}
+	for _, manifest := range plan.prune {
+		result, err := deleteManifest(manifest)
+		if err != nil {
+			return fmt.Errorf("%s failed: %v", manifest, err)
+		}
+		fmt.Printf("%s %s\n", manifest, result)
+	}
+	return nil
+}
+
+// orderRules places every rule right after the previous one, the first rule
+// keeps its position.
+func orderRules(names []string) error {
+	for idx := 1; idx < len(names); idx++ {
+		// a Rule would reset the booleans which are not omitted when empty
+		ruleData, _ := json.Marshal(map[string]string{"name": names[idx], "after_rule": names[idx-1]})
+		_, err := api.PutResourceFromBytes("rule", ruleData)
+		if err != nil {
+			return fmt.Errorf("failed to order rule '%s': %v", names[idx], err)
+		}
+	}
+	return nil
+}
+
+func readSyncInventory(filename string) (SyncInventory, error) {
+	var inventory SyncInventory
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return inventory, nil
+	}
+	if err != nil {
+		return inventory, err
+	}
+	err = yaml.Unmarshal(data, &inventory)
+	return inventory, err
+}
+
+// syncedInventory returns the previous inventory without the pruned
+// resources and with the synced ones.
+func syncedInventory(previous SyncInventory, manifests []Manifest, pruned []Manifest) SyncInventory {
+	var prunedKeys []string
+	for _, manifest := range pruned {
+		prunedKeys = append(prunedKeys, manifest.String())
+	}
+	var inventory SyncInventory
+	for _, key := range previous.Managed {
+		if !helpers.StringInSlice(key, prunedKeys) && !helpers.StringInSlice(key, inventory.Managed) {
+			inventory.Managed = append(inventory.Managed, key)
+		}
+	}
+	for _, manifest := range manifests {
+		if !helpers.StringInSlice(manifest.String(), inventory.Managed) {
+			inventory.Managed = append(inventory.Managed, manifest.String())
+		}
+	}
+	sort.Strings(inventory.Managed)
+	return inventory
+}
+
+func writeSyncInventory(filename string, inventory SyncInventory) error {
+	data, err := yaml.Marshal(inventory)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"vaxctl/helpers"
+)
+
+type SimulateActionsOptions struct {
+	RuleName  string
+	DeviceUID string
+	KeyDelay  time.Duration
+	StepTime  time.Duration
+}
+
+// TimelineStep is a single thing the console receives while a rule runs.
+type TimelineStep struct {
+	Time   string `json:"time" yaml:"time" header:"Time"`
+	Action string `json:"action" yaml:"action" header:"Action"`
+	Type   string `json:"action_type" yaml:"action_type" header:"Type"`
+	Step   string `json:"step" yaml:"step" header:"Step"`
+}
+
+// SimulateActions prints the timeline of the actions of a rule: every key
+// press (typed text is split into keys), sleeps and the other steps with the
+// time they start at, followed by the total expected runtime. The
+// placeholders are resolved when a device is set.
+func SimulateActions(options SimulateActionsOptions) error {
+	rules, err := GetRules(options.RuleName)
+	if err != nil {
+		return err
+	}
+	actions, err := getRuleActions(rules[0])
+	if err != nil {
+		return err
+	}
+	var resolver *placeholderResolver
+	if options.DeviceUID != "" {
+		resolver, err = newPlaceholderResolver(options.DeviceUID, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	var steps []TimelineStep
+	var elapsed time.Duration
+	addStep := func(action Action, step string, duration time.Duration) {
+		steps = append(steps, TimelineStep{Time: elapsed.String(), Action: action.Name, Type: action.Type, Step: step})
+		elapsed += duration
+	}
+	for _, action := range actions {
+		if resolver != nil && action.Type != "keystroke" {
+			maskedResolver := *resolver
+			maskedResolver.showPasswords = false
+			action.Data, err = maskedResolver.render(action.Data)
+			if err != nil {
+				return fmt.Errorf("action '%s' failed to render:\n%v", action.Name, err)
+			}
+		}
+		switch action.Type {
+		case "keystroke":
+			combos, err := helpers.ParseKeystrokes(action.Data)
+			if err != nil {
+				return fmt.Errorf("action '%s' has invalid data: %v", action.Name, err)
+			}
+			for _, combo := range combos {
+				if combo.IsCombination() {
+					addStep(action, "press "+combo.String(), options.KeyDelay)
+					continue
+				}
+				typedKeys, err := typedKeys(combo.Text, resolver)
+				if err != nil {
+					return fmt.Errorf("action '%s' failed to render:\n%v", action.Name, err)
+				}
+				for _, typedKey := range typedKeys {
+					addStep(action, "type "+typedKey, options.KeyDelay)
+				}
+			}
+		case "sleep":
+			seconds, err := strconv.ParseFloat(strings.TrimSpace(action.Data), 64)
+			if err != nil || seconds < 0 {
+				return fmt.Errorf("action '%s' has invalid data: '%s' is not a number of seconds", action.Name, action.Data)
+			}
+			duration := time.Duration(seconds * float64(time.Second))
+			addStep(action, fmt.Sprintf("sleep %s", duration), duration)
+		case "power":
+			addStep(action, "ipmitool power "+action.Data, options.StepTime)
+		case "ipmitool":
+			addStep(action, "ipmitool "+action.Data, options.StepTime)
+		case "request":
+			addStep(action, "GET "+action.Data, options.StepTime)
+		default:
+			addStep(action, action.Data, options.StepTime)
+		}
+	}
+	if len(steps) == 0 {
+		fmt.Printf("Rule '%s' has no actions\n", options.RuleName)
+		return nil
+	}
+	helpers.PrintTable(steps)
+	fmt.Printf("\nTotal expected runtime: %s (%d steps)\n", elapsed, len(steps))
+	return nil
+}
+
+// typedKeys breaks typed text into keys, placeholders are resolved with the
+// resolver (passwords are typed as '*') or typed as a single step without it.
+func typedKeys(text string, resolver *placeholderResolver) ([]string, error) {
+	var keys []string
+	addKeys := func(text string, masked bool) {
+		for _, char := range text {
+			if masked {
+				char = '*'
+			}
+			keys = append(keys, typedKeyName(char))
+		}
+	}
+	offset := 0
+	for _, match := range placeholderRegex.FindAllStringSubmatchIndex(text, -1) {
+		addKeys(text[offset:match[0]], false)
+		offset = match[1]
+		placeholder := text[match[0]:match[1]]
+		if resolver == nil {
+			keys = append(keys, placeholder)
+			continue
+		}
+		value, err := resolver.resolve(text[match[2]:match[3]], text[match[4]:match[5]])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", placeholder, err)
+		}
+		addKeys(value, strings.HasSuffix(placeholder, "password}"))
+	}
+	addKeys(text[offset:], false)
+	return keys, nil
+}
+
+// typedKeyName names the key pressed for a character of typed text,
+// whitespace is named after its special key.
+func typedKeyName(char rune) string {
+	switch char {
+	case ' ':
+		return "Keys.Space"
+	case '\t':
+		return "Keys.Tab"
+	case '\n':
+		return "Keys.Enter"
+	}
+	return fmt.Sprintf("'%c'", char)
+}
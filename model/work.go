@@ -89,8 +89,17 @@ func GetWorks(workId string, deviceUID string, showDetails bool, latest bool, ou
 func AssignWork(deviceUID string, ruleName string, actionsList []string, filename string) error {
 	var err error
 	if filename != "" {
-		_, err = api.PostResourceFromFile("work", filename)
+		var manifest Manifest
+		manifest, err = readExpandedFile("work", filename)
+		if err != nil {
+			return err
+		}
+		_, err = api.CreateWorkAssignment(manifest.Data)
 	} else {
+		actionsList, _, err = expandActionNames(actionsList)
+		if err != nil {
+			return err
+		}
 		workAssignmentData, _ := json.Marshal(WorkAssignment{deviceUID, ruleName, actionsList})
 		_, err = api.CreateWorkAssignment(workAssignmentData)
 	}
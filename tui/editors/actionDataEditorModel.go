@@ -2,7 +2,6 @@ package editors
 
 import (
 	"fmt"
-	"strings"
 	"vaxctl/tui/common"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -60,10 +59,10 @@ func NewActionDataEditor(actionType string, actionData string, powerOptions []st
 
 	help := common.GetHelpModel()
 
-	var keycomboList []string
+	var keycomboData string
 	switch actionType {
 	case keysrokeType:
-		keycomboList = strings.Split(actionData, ";")
+		keycomboData = actionData
 	case ipmitoolType:
 		ipmitoolInput.SetValue(actionData)
 	case powerType:
@@ -79,7 +78,7 @@ func NewActionDataEditor(actionType string, actionData string, powerOptions []st
 		requestInput.SetValue(actionData)
 	}
 
-	keycomboInput := NewActionKeycomboEditorModel("Key Combo list", keycomboList, specialKeys)
+	keycomboInput := NewActionKeycomboEditorModel("Key Combo list", keycomboData, specialKeys)
 	return ActionDataEditorModel{
 		actionType:    actionType,
 		ipmitoolInput: ipmitoolInput,
@@ -94,7 +93,7 @@ func NewActionDataEditor(actionType string, actionData string, powerOptions []st
 func (m *ActionDataEditorModel) SetValue(value string) {
 	switch m.actionType {
 	case keysrokeType:
-		m.keycomboInput.SetValue(strings.Split(value, ";"))
+		m.keycomboInput.SetValue(value)
 	case ipmitoolType:
 		m.ipmitoolInput.SetValue(value)
 	case powerType:
@@ -126,7 +125,7 @@ func (m ActionDataEditorModel) Value() string {
 	var value string
 	switch m.actionType {
 	case keysrokeType:
-		value = strings.Join(m.keycomboInput.Value(), ";")
+		value = m.keycomboInput.Value()
 	case ipmitoolType:
 		value = m.ipmitoolInput.Value()
 	case powerType:
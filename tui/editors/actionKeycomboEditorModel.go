@@ -3,6 +3,7 @@ package editors
 import (
 	"fmt"
 	"strings"
+	"vaxctl/helpers"
 	"vaxctl/tui/common"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -33,21 +34,11 @@ type ActionKeycomboEditorModel struct {
 	selectedItemIndex int
 	selectedItemMode  string
 	help              help.Model
+	err               error
 }
 
-func NewActionKeycomboEditorModel(title string, itemList []string, specialKeys []string) ActionKeycomboEditorModel {
-	var listOfItems []list.Item
-	for _, item := range itemList {
-		var keyType string
-		if strings.HasPrefix(item, "Keys.") {
-			keyType = specialType
-		} else {
-			keyType = sequenceType
-		}
-		listOfItems = append(listOfItems, common.TypedItem{Value: item, Type: keyType})
-	}
-
-	actionList := list.New(listOfItems, common.TypedItemDelegate{}, 1, 1)
+func NewActionKeycomboEditorModel(title string, actionData string, specialKeys []string) ActionKeycomboEditorModel {
+	actionList := list.New(keycomboItems(actionData), common.TypedItemDelegate{}, 1, 1)
 	actionList.Title = title
 	actionList.SetShowStatusBar(false)
 	actionList.SetFilteringEnabled(false)
@@ -73,18 +64,26 @@ func NewActionKeycomboEditorModel(title string, itemList []string, specialKeys [
 	}
 }
 
-func (m *ActionKeycomboEditorModel) SetValue(itemList []string) {
+// keycomboItems parses the action data, combos that can't be parsed are
+// kept as they are so they can be fixed.
+func keycomboItems(actionData string) []list.Item {
 	var listOfItems []list.Item
-	for _, item := range itemList {
-		var keyType string
-		if strings.HasPrefix(item, "Keys.") {
+	if actionData == "" {
+		return listOfItems
+	}
+	for _, item := range strings.Split(actionData, helpers.KeystrokeComboSeparator) {
+		keyType := sequenceType
+		combo, err := helpers.ParseKeystrokeCombo(item)
+		if err == nil && combo.IsCombination() {
 			keyType = specialType
-		} else {
-			keyType = sequenceType
 		}
 		listOfItems = append(listOfItems, common.TypedItem{Value: item, Type: keyType})
 	}
-	m.actionList.SetItems(listOfItems)
+	return listOfItems
+}
+
+func (m *ActionKeycomboEditorModel) SetValue(actionData string) {
+	m.actionList.SetItems(keycomboItems(actionData))
 }
 
 func (m *ActionKeycomboEditorModel) EdittingMode() bool {
@@ -98,12 +97,12 @@ func (m *ActionKeycomboEditorModel) SetSize(width int, height int) {
 	m.help.Width = width / 2
 }
 
-func (m ActionKeycomboEditorModel) Value() []string {
+func (m ActionKeycomboEditorModel) Value() string {
 	var itemList []string
 	for _, item := range m.actionList.Items() {
 		itemList = append(itemList, item.(common.TypedItem).Value)
 	}
-	return itemList
+	return strings.Join(itemList, helpers.KeystrokeComboSeparator)
 }
 
 func (m ActionKeycomboEditorModel) Update(msg tea.Msg) (ActionKeycomboEditorModel, tea.Cmd) {
@@ -174,7 +173,6 @@ func (m ActionKeycomboEditorModel) Update(msg tea.Msg) (ActionKeycomboEditorMode
 			case tea.KeyMsg:
 				switch {
 				case key.Matches(msg, common.ConfirmKeys.ApplyData):
-					m.mode = keyComboListMode
 					var newValue string
 					switch m.selectedItemType {
 					case sequenceType:
@@ -182,6 +180,18 @@ func (m ActionKeycomboEditorModel) Update(msg tea.Msg) (ActionKeycomboEditorMode
 					case specialType:
 						newValue = m.specialInput.Value()
 					}
+					combo, err := helpers.ParseKeystrokeCombo(newValue)
+					if err != nil {
+						m.err = err
+						break
+					}
+					m.err = nil
+					m.mode = keyComboListMode
+					if combo.IsCombination() {
+						m.selectedItemType = specialType
+					} else {
+						m.selectedItemType = sequenceType
+					}
 					switch m.selectedItemMode {
 					case keyComboItemCreation:
 						newIndex := len(m.actionList.Items())
@@ -192,6 +202,7 @@ func (m ActionKeycomboEditorModel) Update(msg tea.Msg) (ActionKeycomboEditorMode
 					}
 					cmds = append(cmds, cmd)
 				case key.Matches(msg, common.ConfirmKeys.ExitMode):
+					m.err = nil
 					m.mode = keyComboListMode
 				}
 			}
@@ -225,6 +236,9 @@ func (m ActionKeycomboEditorModel) View() string {
 		case specialType:
 			str = m.specialInput.View()
 		}
+		if m.err != nil {
+			str = lipgloss.JoinVertical(lipgloss.Left, str, common.InvalidStyle.Render(m.err.Error()))
+		}
 	}
 	return str
 }
@@ -3,6 +3,7 @@ package editors
 import (
 	"fmt"
 	"strings"
+	"vaxctl/helpers"
 	"vaxctl/tui/common"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -80,16 +81,13 @@ func (m *SpecialKeyEditorModel) SetSize(width int, height int) {
 
 func (m *SpecialKeyEditorModel) SetValue(newValue string) tea.Cmd {
 	var listOfItems []list.Item
-	if newValue != "" {
-		for _, keyStr := range strings.Split(newValue, "+") {
-			var keyType string
-			if strings.HasPrefix(keyStr, "Keys.") {
-				keyType = specialType
-			} else {
-				keyType = sequenceType
-			}
-			listOfItems = append(listOfItems, common.TypedItem{Type: keyType, Value: keyStr})
+	combo, _ := helpers.ParseKeystrokeCombo(newValue)
+	for _, comboKey := range combo.Keys {
+		keyType := charType
+		if comboKey.Special {
+			keyType = specialKeyType
 		}
+		listOfItems = append(listOfItems, common.TypedItem{Type: keyType, Value: comboKey.String()})
 	}
 	return m.keysList.SetItems(listOfItems)
 }
@@ -99,7 +97,7 @@ func (m SpecialKeyEditorModel) Value() string {
 	for _, item := range m.keysList.Items() {
 		itemList = append(itemList, item.(common.TypedItem).Value)
 	}
-	return strings.Join(itemList, "+")
+	return strings.Join(itemList, helpers.KeystrokeKeySeparator)
 }
 
 func (m SpecialKeyEditorModel) Update(msg tea.Msg) (SpecialKeyEditorModel, tea.Cmd) {
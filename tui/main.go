@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"io/ioutil"
 	"os"
+	"vaxctl/api"
 	"vaxctl/model"
 	"vaxctl/tui/common"
 	"vaxctl/tui/models"
@@ -87,6 +89,8 @@ func InteractiveNavigationMenu(data common.InteractiveData) error {
 	if err != nil {
 		return err
 	}
+	// skipped requests are reported on the status line instead
+	api.DryRunOutput = ioutil.Discard
 	p := tea.NewProgram(models.InitialNavigationModel(data))
 	err = p.Start()
 	return err
@@ -210,10 +210,21 @@ func (m ActionModel) Update(msg tea.Msg) (ActionModel, tea.Cmd) {
 			if err != nil {
 				m.StatusMessage = getStatusMessage(err.Error(), true)
 			} else {
-				m.StatusMessage = getStatusMessage("Saved to Server!", false)
+				m.StatusMessage = getStatusMessage(savedToServerMessage(), false)
 				m.updateActions()
 				return m, common.UpdateActionNames()
 			}
+		case duplicateAction:
+			copyName, err := duplicateName("action", m.ActionName)
+			if err != nil {
+				m.StatusMessage = getStatusMessage(err.Error(), true)
+			} else {
+				m.ActionName = copyName
+				m.actionNameInput.SetValue(m.ActionName)
+				m.actionResourceData.SetValue(actionNameOption, m.ActionName)
+				m.StatusMessage = getStatusMessage(duplicatedMessage(copyName), false)
+				m.CurrentView = dataView
+			}
 		case clearFieldsAction:
 			m.ActionName = ""
 			m.ActionType = ""
@@ -228,9 +228,20 @@ func (m CredModel) Update(msg tea.Msg) (CredModel, tea.Cmd) {
 				m.StatusMessage = getStatusMessage(err.Error(), true)
 			} else {
 				m.updateCreds()
-				m.StatusMessage = getStatusMessage("Saved to Server!", false)
+				m.StatusMessage = getStatusMessage(savedToServerMessage(), false)
 				return m, common.UpdateCredNames()
 			}
+		case duplicateAction:
+			copyName, err := duplicateName("creds", m.CredName)
+			if err != nil {
+				m.StatusMessage = getStatusMessage(err.Error(), true)
+			} else {
+				m.CredName = copyName
+				m.credNameInput.SetValue(m.CredName)
+				m.credResourceData.SetValue(credNameOption, m.CredName)
+				m.StatusMessage = getStatusMessage(duplicatedMessage(copyName), false)
+				m.CurrentView = dataView
+			}
 		case clearFieldsAction:
 			m.CredName = ""
 			m.Username = ""
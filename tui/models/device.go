@@ -255,7 +255,22 @@ func (m DeviceModel) Update(msg tea.Msg) (DeviceModel, tea.Cmd) {
 				m.StatusMessage = getStatusMessage(err.Error(), true)
 			} else {
 				m.updateDevices()
-				m.StatusMessage = getStatusMessage("Saved to Server!", false)
+				m.StatusMessage = getStatusMessage(savedToServerMessage(), false)
+			}
+		case duplicateAction:
+			copyName, err := duplicateName("device", m.DeviceUID)
+			if err != nil {
+				m.StatusMessage = getStatusMessage(err.Error(), true)
+			} else {
+				// a copied device needs its own IPMI IP
+				m.DeviceUID = copyName
+				m.IpmiIP = ""
+				m.deviceUIDInput.SetValue(m.DeviceUID)
+				m.ipmiIPInput.SetValue(m.IpmiIP)
+				m.deviceResourceData.SetValue(deviceUIDOption, m.DeviceUID)
+				m.deviceResourceData.SetValue(deviceIPOption, m.IpmiIP)
+				m.StatusMessage = getStatusMessage(duplicatedMessage(copyName), false)
+				m.CurrentView = dataView
 			}
 		case clearFieldsAction:
 			m.DeviceUID = ""
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"fmt"
 	"strings"
+	"vaxctl/api"
+	"vaxctl/model"
 	"vaxctl/tui/common"
 
 	"github.com/charmbracelet/lipgloss"
@@ -13,6 +16,7 @@ const (
 	showYamlAction     = "Show YAML"
 	saveToFileAction   = "Save To File"
 	saveToServerAction = "Save To Server"
+	duplicateAction    = "Duplicate"
 	clearFieldsAction  = "Clear Fields"
 	backAction         = "Back"
 	createRuleAction   = "Create Rule from State"
@@ -34,7 +38,7 @@ const (
 )
 
 var (
-	mainActions      = []string{showYamlAction, saveToFileAction, saveToServerAction, clearFieldsAction, backAction}
+	mainActions      = []string{showYamlAction, saveToFileAction, saveToServerAction, duplicateAction, clearFieldsAction, backAction}
 	stateMainActions = []string{saveToServerAction, createRuleAction, clearFieldsAction, backAction}
 	views            = []string{mainView, dataView, dynamicView, viewerView}
 )
@@ -77,6 +81,28 @@ func getWindowStyles(width int, height int) (lipgloss.Style, lipgloss.Style, lip
 	return mainStyle, dataStyle, dynamicStyle, viewerStyle
 }
 
+// savedToServerMessage notes that nothing was saved when running on dry run
+// mode, the skipped requests are not printed since they would break the view.
+func savedToServerMessage() string {
+	if api.IsDryRun() {
+		return fmt.Sprintf("Dry run: %s was not sent", api.LastDryRunRequest())
+	}
+	return "Saved to Server!"
+}
+
+// duplicateName returns an unused name for a copy of the resource in the
+// form, the copy is created by saving it to the server.
+func duplicateName(kind string, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("Nothing to duplicate, select a resource first")
+	}
+	return model.CopyName(kind, name)
+}
+
+func duplicatedMessage(name string) string {
+	return fmt.Sprintf("Duplicated as '%s', change it and save it to the server", name)
+}
+
 func getStatusMessage(status string, isError bool) string {
 	var statusLine string
 	if isError {
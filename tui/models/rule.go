@@ -276,7 +276,18 @@ func (m RuleModel) Update(msg tea.Msg) (RuleModel, tea.Cmd) {
 				m.StatusMessage = getStatusMessage(err.Error(), true)
 			} else {
 				m.updateRules()
-				m.StatusMessage = getStatusMessage("Saved to Server!", false)
+				m.StatusMessage = getStatusMessage(savedToServerMessage(), false)
+			}
+		case duplicateAction:
+			copyName, err := duplicateName("rule", m.RuleName)
+			if err != nil {
+				m.StatusMessage = getStatusMessage(err.Error(), true)
+			} else {
+				m.RuleName = copyName
+				m.ruleNameInput.SetValue(m.RuleName)
+				m.ruleResourceData.SetValue(ruleColumnKeyName, m.RuleName)
+				m.StatusMessage = getStatusMessage(duplicatedMessage(copyName), false)
+				m.CurrentView = dataView
 			}
 		case clearFieldsAction:
 			m.RuleName = ""
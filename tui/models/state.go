@@ -123,7 +123,7 @@ func (m StateModel) Update(msg tea.Msg) (StateModel, tea.Cmd) {
 				m.StatusMessage = getStatusMessage(err.Error(), true)
 			} else {
 				m.updateStates()
-				m.StatusMessage = getStatusMessage("Saved to Server!", false)
+				m.StatusMessage = getStatusMessage(savedToServerMessage(), false)
 			}
 		case createRuleAction:
 			if m.StateId != 0 {